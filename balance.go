@@ -1,8 +1,17 @@
 package hitbtc
 
+import (
+	"github.com/shopspring/decimal"
+)
+
 // Balance represents a cryptocurrency balance on the exchange
 type Balance struct {
-	Currency  string  `json:"currency"`
-	Available float64 `json:"available,string"`
-	Reserved  float64 `json:"reserved,string"`
+	Currency  string          `json:"currency"`
+	Available decimal.Decimal `json:"available"`
+	Reserved  decimal.Decimal `json:"reserved"`
+}
+
+// Total returns the available and reserved amounts together.
+func (b Balance) Total() decimal.Decimal {
+	return b.Available.Add(b.Reserved)
 }
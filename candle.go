@@ -0,0 +1,56 @@
+package hitbtc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Period is the timeframe of candles.
+type Period string
+
+// Candle periods.
+const (
+	PeriodM1  Period = "M1"  // 1 minute
+	PeriodM3  Period = "M3"  // 3 minutes
+	PeriodM5  Period = "M5"  // 5 minutes
+	PeriodM15 Period = "M15" // 15 minutes
+	PeriodM30 Period = "M30" // 30 minutes
+	PeriodH1  Period = "H1"  // 1 hour
+	PeriodH4  Period = "H4"  // 4 hours
+	PeriodD1  Period = "D1"  // 1 day
+	PeriodD7  Period = "D7"  // 7 days
+	Period1M  Period = "1M"  // 1 month
+)
+
+// Candle represents the OHLCV data of a symbol over a period.
+type Candle struct {
+	Timestamp   time.Time       `json:"timestamp"`
+	Open        decimal.Decimal `json:"open"`
+	Close       decimal.Decimal `json:"close"`
+	Min         decimal.Decimal `json:"min"`
+	Max         decimal.Decimal `json:"max"`
+	Volume      decimal.Decimal `json:"volume"`      // Total trading amount within the period in base currency
+	VolumeQuote decimal.Decimal `json:"volumeQuote"` // Total trading amount within the period in quote currency
+}
+
+// UnmarshalJSON allows the obejct to be JSON Unmarshallable.
+func (c *Candle) UnmarshalJSON(data []byte) error {
+	var err error
+	type Alias Candle
+	aux := &struct {
+		Timestamp string `json:"timestamp"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	c.Timestamp, err = time.Parse("2006-01-02T15:04:05.999Z", aux.Timestamp)
+	if err != nil {
+		return err
+	}
+	return nil
+}
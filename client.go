@@ -1,6 +1,7 @@
 package hitbtc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 )
@@ -15,92 +17,95 @@ import (
 type client struct {
 	apiKey      string
 	apiSecret   string
+	baseURL     string
+	userAgent   string
 	httpClient  *http.Client
 	httpTimeout time.Duration
 	debug       bool
+	logger      Logger
+	limiter     *RateLimiter
+	retry       RetryPolicy
+
+	normalization *RoundingPolicy
 }
 
-// NewClient return a new HitBtc HTTP client
-func NewClient(apiKey, apiSecret string) (c *client) {
-	return &client{apiKey, apiSecret, &http.Client{}, 30 * time.Second, false}
+// NewClient return a new HitBtc HTTP client configured by opts
+func NewClient(apiKey, apiSecret string, opts ...Option) (c *client) {
+	c = &client{
+		apiKey:      apiKey,
+		apiSecret:   apiSecret,
+		baseURL:     API_BASE,
+		httpClient:  &http.Client{},
+		httpTimeout: 30 * time.Second,
+		logger:      log.New(os.Stderr, "", log.LstdFlags),
+		limiter:     NewRateLimiter(DefaultRateLimits),
+		retry:       DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // NewClientWithCustomHttpConfig returns a new HitBtc HTTP client using the predefined http client
 func NewClientWithCustomHttpConfig(apiKey, apiSecret string, httpClient *http.Client) (c *client) {
-	timeout := httpClient.Timeout
-	if timeout <= 0 {
-		timeout = 30 * time.Second
-	}
-	return &client{apiKey, apiSecret, httpClient, timeout, false}
+	return NewClient(apiKey, apiSecret, WithHTTPClient(httpClient))
 }
 
-// NewClient returns a new HitBtc HTTP client with custom timeout
+// NewClientWithCustomTimeout returns a new HitBtc HTTP client with custom timeout
 func NewClientWithCustomTimeout(apiKey, apiSecret string, timeout time.Duration) (c *client) {
-	return &client{apiKey, apiSecret, &http.Client{}, timeout, false}
+	return NewClient(apiKey, apiSecret, WithTimeout(timeout))
 }
 
 func (c client) dumpRequest(r *http.Request) {
 	if r == nil {
-		log.Print("dumpReq ok: <nil>")
+		c.logger.Printf("dumpReq ok: <nil>")
 		return
 	}
 	dump, err := httputil.DumpRequest(r, true)
 	if err != nil {
-		log.Print("dumpReq err:", err)
+		c.logger.Printf("dumpReq err: %v", err)
 	} else {
-		log.Print("dumpReq ok:", string(dump))
+		c.logger.Printf("dumpReq ok: %s", dump)
 	}
 }
 
 func (c client) dumpResponse(r *http.Response) {
 	if r == nil {
-		log.Print("dumpResponse ok: <nil>")
+		c.logger.Printf("dumpResponse ok: <nil>")
 		return
 	}
 	dump, err := httputil.DumpResponse(r, true)
 	if err != nil {
-		log.Print("dumpResponse err:", err)
+		c.logger.Printf("dumpResponse err: %v", err)
 	} else {
-		log.Print("dumpResponse ok:", string(dump))
+		c.logger.Printf("dumpResponse ok: %s", dump)
 	}
 }
 
-// doTimeoutRequest do a HTTP request with timeout
-func (c *client) doTimeoutRequest(timer *time.Timer, req *http.Request) (*http.Response, error) {
-	// Do the request in the background so we can check the timeout
-	type result struct {
-		resp *http.Response
-		err  error
+// doRequest sends req, dumping it and its response when debug is enabled.
+func (c *client) doRequest(req *http.Request) (*http.Response, error) {
+	if c.debug {
+		c.dumpRequest(req)
 	}
-	done := make(chan result, 1)
-	go func() {
-		if c.debug {
-			c.dumpRequest(req)
-		}
-		resp, err := c.httpClient.Do(req)
-		if c.debug {
-			c.dumpResponse(resp)
-		}
-		done <- result{resp, err}
-	}()
-	// Wait for the read or the timeout
-	select {
-	case r := <-done:
-		return r.resp, r.err
-	case <-timer.C:
-		return nil, errors.New("timeout on reading data from HitBtc API")
+	resp, err := c.httpClient.Do(req)
+	if c.debug {
+		c.dumpResponse(resp)
+	}
+	if err != nil && req.Context().Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timeout on reading data from HitBtc API: %w", context.DeadlineExceeded)
 	}
+	return resp, err
 }
 
 // do prepare and process HTTP request to HitBtc API
-func (c *client) do(method string, resource string, payload map[string]string, authNeeded bool) (response []byte, err error) {
-	connectTimer := time.NewTimer(c.httpTimeout)
-
+// Idempotent requests are retried according to the retry policy of the client.
+func (c *client) do(ctx context.Context, method string, resource string, payload map[string]string, authNeeded bool) (response []byte, err error) {
 	var rawurl string
 	if strings.HasPrefix(resource, "http") {
 		rawurl = resource
 	} else {
-		rawurl = fmt.Sprintf("%s/%s", API_BASE, resource)
+		rawurl = fmt.Sprintf("%s/%s", c.baseURL, resource)
 	}
 	var formData string
 	if method == "GET" {
@@ -123,23 +128,63 @@ func (c *client) do(method string, resource string, payload map[string]string, a
 		}
 		formData = formValues.Encode()
 	}
-	req, err := http.NewRequest(method, rawurl, strings.NewReader(formData))
+
+	// Auth
+	if authNeeded && (len(c.apiKey) == 0 || len(c.apiSecret) == 0) {
+		err = errors.New("You need to set API Key and API Secret to call this method")
+		return
+	}
+
+	attempts := 1
+	if isIdempotent(method, resource) && c.retry.MaxAttempts > 1 {
+		attempts = c.retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		response, err = c.doOnce(ctx, method, rawurl, rateCategory(resource), formData, authNeeded)
+		if attempt > 1 && method == http.MethodPut && isDuplicateClientOrderId(err) {
+			// a previous attempt placed the order but its response was lost
+			if placed, getErr := c.doOnce(ctx, http.MethodGet, rawurl, rateCategory(resource), "", authNeeded); getErr == nil {
+				return placed, nil
+			}
+		}
+		if err == nil || attempt >= attempts || !isRetryable(ctx, err) {
+			return response, err
+		}
+		if c.debug {
+			c.logger.Printf("retrying %s %s after error: %v", method, rawurl, err)
+		}
+		if sleepErr := sleep(ctx, c.retry.delay(attempt, err)); sleepErr != nil {
+			return response, err
+		}
+	}
+}
+
+// doOnce performs a single attempt of a request prepared by do.
+func (c *client) doOnce(ctx context.Context, method string, rawurl string, category RateCategory, formData string, authNeeded bool) (response []byte, err error) {
+	if c.limiter != nil {
+		if err = c.limiter.Wait(ctx, category); err != nil {
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.httpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, rawurl, strings.NewReader(formData))
 	if err != nil {
 		return
 	}
 
 	req.Header.Add("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 
-	// Auth
 	if authNeeded {
-		if len(c.apiKey) == 0 || len(c.apiSecret) == 0 {
-			err = errors.New("You need to set API Key and API Secret to call this method")
-			return
-		}
 		req.SetBasicAuth(c.apiKey, c.apiSecret)
 	}
 
-	resp, err := c.doTimeoutRequest(connectTimer, req)
+	resp, err := c.doRequest(req)
 	if err != nil {
 		return
 	}
@@ -149,8 +194,10 @@ func (c *client) do(method string, resource string, payload map[string]string, a
 	if err != nil {
 		return response, err
 	}
-	if resp.StatusCode != 200 && resp.StatusCode != 401 {
-		err = errors.New(resp.Status)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := newAPIError(resp.StatusCode, response)
+		apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		err = apiErr
 	}
 	return response, err
 }
@@ -0,0 +1,46 @@
+package hitbtc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jsonrpc2 "github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/require"
+)
+
+type blockingHandler struct {
+	release chan struct{}
+	handled int64
+}
+
+func (h *blockingHandler) Handle(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) {
+	<-h.release
+	atomic.AddInt64(&h.handled, 1)
+}
+
+func TestOrderedHandlerBackpressure(t *testing.T) {
+	handler := &blockingHandler{release: make(chan struct{})}
+	h := newOrderedHandler(handler)
+	defer h.close()
+
+	// the reader: one message handled (and blocked), then a full queue
+	var read int64
+	total := int64(maxQueuedMessages + 10)
+	go func() {
+		for i := int64(0); i < total; i++ {
+			h.Handle(context.Background(), nil, &jsonrpc2.Request{Method: "ticker"})
+			atomic.AddInt64(&read, 1)
+		}
+	}()
+
+	stalled := int64(maxQueuedMessages + 1)
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&read) == stalled }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, stalled, atomic.LoadInt64(&read), "the reader stalls while the handler blocks")
+	require.Len(t, h.queue, maxQueuedMessages, "the queue is bounded")
+
+	close(handler.release)
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&handler.handled) == total }, 5*time.Second, 10*time.Millisecond)
+}
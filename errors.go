@@ -0,0 +1,121 @@
+package hitbtc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Error codes returned by the HitBTC API.
+const (
+	ErrCodeRateLimited            = 429
+	ErrCodeInternal               = 500
+	ErrCodeServiceUnavailable     = 503
+	ErrCodeGatewayTimeout         = 504
+	ErrCodeAuthRequired           = 1001
+	ErrCodeAuthFailed             = 1002
+	ErrCodeActionForbidden        = 1003
+	ErrCodeUnsupportedAuth        = 1004
+	ErrCodeSymbolNotFound         = 2001
+	ErrCodeCurrencyNotFound       = 2002
+	ErrCodeQuantityTooLow         = 2010
+	ErrCodeQuantityTooLowOnBuy    = 2011
+	ErrCodePriceTooLow            = 2012
+	ErrCodePriceTooLowOnBuy       = 2020
+	ErrCodeValidation             = 10001
+	ErrCodeInsufficientFunds      = 20001
+	ErrCodeOrderNotFound          = 20002
+	ErrCodeLimitExceeded          = 20003
+	ErrCodeTransactionNotFound    = 20004
+	ErrCodePayoutNotFound         = 20005
+	ErrCodePayoutAlreadyCommitted = 20006
+	ErrCodePayoutAlreadyRolled    = 20007
+	ErrCodeDuplicateClientOrderId = 20008
+	ErrCodeAddressGeneration      = 20010
+	ErrCodeWithdrawalNotFound     = 20011
+	ErrCodeWithdrawalsDisabled    = 20012
+	ErrCodeWithdrawalTooSmall     = 20013
+	ErrCodeWithdrawalTooLarge     = 20014
+)
+
+// APIError represents an error returned by the HitBTC API.
+type APIError struct {
+	Code        int           `json:"code"`
+	Message     string        `json:"message"`
+	Description string        `json:"description"`
+	HTTPStatus  int           `json:"-"` // zero when the error came with a successful HTTP response
+	Body        []byte        `json:"-"` // raw response body
+	RetryAfter  time.Duration `json:"-"` // delay requested by the Retry-After header, if any
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.HTTPStatus)
+	}
+	if e.Description != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Description)
+	}
+	if e.Code != 0 {
+		return fmt.Sprintf("hitbtc: %s (code %d)", msg, e.Code)
+	}
+	return "hitbtc: " + msg
+}
+
+// newAPIError builds an APIError from a response status and body.
+// The HitBTC error object is decoded from the body when present.
+func newAPIError(status int, body []byte) *APIError {
+	var envelope struct {
+		Error *APIError `json:"error"`
+	}
+	apiErr := &APIError{}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error != nil {
+		apiErr = envelope.Error
+	}
+	apiErr.HTTPStatus = status
+	apiErr.Body = body
+	return apiErr
+}
+
+// errorCode returns the HitBTC error code and HTTP status carried by err.
+func errorCode(err error) (code int, status int, ok bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return 0, 0, false
+	}
+	return apiErr.Code, apiErr.HTTPStatus, true
+}
+
+// IsInsufficientFunds reports whether err is a HitBTC "insufficient funds" error.
+func IsInsufficientFunds(err error) bool {
+	code, _, ok := errorCode(err)
+	return ok && code == ErrCodeInsufficientFunds
+}
+
+// IsOrderNotFound reports whether err is a HitBTC "order not found" error.
+func IsOrderNotFound(err error) bool {
+	code, _, ok := errorCode(err)
+	return ok && code == ErrCodeOrderNotFound
+}
+
+// IsRateLimited reports whether err means the request was rejected by the HitBTC rate limiter.
+func IsRateLimited(err error) bool {
+	code, status, ok := errorCode(err)
+	return ok && (code == ErrCodeRateLimited || status == http.StatusTooManyRequests)
+}
+
+// IsAuthError reports whether err is an authentication or authorization failure.
+func IsAuthError(err error) bool {
+	code, status, ok := errorCode(err)
+	if !ok {
+		return false
+	}
+	switch code {
+	case ErrCodeAuthRequired, ErrCodeAuthFailed, ErrCodeActionForbidden, ErrCodeUnsupportedAuth:
+		return true
+	}
+	return status == http.StatusUnauthorized
+}
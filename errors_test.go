@@ -0,0 +1,39 @@
+package hitbtc
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIError(t *testing.T) {
+	body := []byte(`{"error":{"code":20001,"message":"Insufficient funds","description":"Check that the funds are sufficient"}}`)
+	err := newAPIError(http.StatusBadRequest, body)
+	require.Equal(t, ErrCodeInsufficientFunds, err.Code)
+	require.Equal(t, "Insufficient funds", err.Message)
+	require.Equal(t, "Check that the funds are sufficient", err.Description)
+	require.Equal(t, http.StatusBadRequest, err.HTTPStatus)
+	require.Equal(t, body, err.Body)
+
+	wrapped := fmt.Errorf("placing order: %w", err)
+	require.True(t, IsInsufficientFunds(wrapped))
+	require.False(t, IsOrderNotFound(wrapped))
+	require.False(t, IsRateLimited(wrapped))
+}
+
+func TestNewAPIErrorWithoutBody(t *testing.T) {
+	err := newAPIError(http.StatusTooManyRequests, []byte("<html>Too Many Requests</html>"))
+	require.Zero(t, err.Code)
+	require.True(t, IsRateLimited(err))
+	require.Equal(t, "hitbtc: Too Many Requests", err.Error())
+}
+
+func TestHandleErr(t *testing.T) {
+	err := handleErr(map[string]interface{}{
+		"error": map[string]interface{}{"code": float64(20002), "message": "Order not found"},
+	})
+	require.True(t, IsOrderNotFound(err))
+	require.NoError(t, handleErr([]interface{}{}))
+}
@@ -0,0 +1,206 @@
+package hitbtc
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/juju/errors"
+)
+
+// SlowConsumerPolicy tells what a websocket feed does with a notification
+// when its buffer is full because the consumer does not keep up.
+type SlowConsumerPolicy int
+
+const (
+	// Block waits until the consumer reads. Notifications are handled in order
+	// of arrival, so a blocked feed delays every other feed of the client, and
+	// once a bounded number of notifications is queued, it stops reading the
+	// connection, delaying the calls of the client too. The heartbeat does not
+	// time out meanwhile: the connection is kept until the consumer reads.
+	Block SlowConsumerPolicy = iota
+	// DropOldest discards the oldest buffered notification to make room for the new one.
+	DropOldest
+	// DropNewest discards the new notification.
+	DropNewest
+	// Disconnect discards the new notification, reports ErrSlowConsumer on
+	// Errors and unsubscribes the feed, closing its channels.
+	Disconnect
+)
+
+func (p SlowConsumerPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop-oldest"
+	case DropNewest:
+		return "drop-newest"
+	case Disconnect:
+		return "disconnect"
+	}
+	return "unknown"
+}
+
+// FeedKind is a kind of websocket feed, for the settings of its channels.
+type FeedKind string
+
+// Feed kinds.
+const (
+	FeedTicker    FeedKind = "ticker"
+	FeedOrderbook FeedKind = "orderbook"
+	FeedTrades    FeedKind = "trades"
+	FeedCandles   FeedKind = "candles"
+	FeedReports   FeedKind = "reports"
+)
+
+// FeedConfig sets the channels of a websocket feed.
+type FeedConfig struct {
+	Buffer int // number of notifications buffered for the consumer
+	Policy SlowConsumerPolicy
+}
+
+// DefaultFeedConfig is the configuration of the feeds of new websocket clients.
+var DefaultFeedConfig = FeedConfig{Buffer: 256, Policy: Block}
+
+// errorsFeedConfig is the configuration of the Errors channel, which never blocks.
+var errorsFeedConfig = FeedConfig{Buffer: 16, Policy: DropOldest}
+
+// ErrSlowConsumer is reported on Errors when a feed with the Disconnect policy
+// is unsubscribed because its consumer did not keep up.
+var ErrSlowConsumer = errors.New("Hitbtc websocket feed consumer too slow")
+
+// feedOptions are the settings of a new feed.
+type feedOptions struct {
+	FeedConfig
+	dropped *uint64 // counter of discarded notifications, shared by the feeds of a subscription
+	evict   func()  // unsubscribes the feed, for the Disconnect policy
+}
+
+// feed is the channel of a websocket subscription. Sending and closing are
+// synchronized, so a feed can be closed by an unsubscribe or by Close while a
+// notification is being delivered on it.
+type feed[T any] struct {
+	ch      chan T
+	done    chan struct{}
+	mu      sync.Mutex // held while sending, so that ch is never closed under a sender
+	once    sync.Once
+	opts    feedOptions
+	evicted bool
+}
+
+func newFeed[T any](opts feedOptions) *feed[T] {
+	if opts.Buffer < 0 {
+		opts.Buffer = 0
+	}
+	if opts.dropped == nil {
+		opts.dropped = new(uint64)
+	}
+	return &feed[T]{ch: make(chan T, opts.Buffer), done: make(chan struct{}), opts: opts}
+}
+
+// send delivers msg following the slow-consumer policy of the feed.
+func (f *feed[T]) send(msg T) {
+	f.mu.Lock()
+	evict := f.deliver(msg)
+	f.mu.Unlock()
+	if evict != nil {
+		go evict()
+	}
+}
+
+// deliver sends msg and returns the eviction to run, if any. f.mu must be held.
+func (f *feed[T]) deliver(msg T) (evict func()) {
+	select {
+	case <-f.done:
+		return nil
+	default:
+	}
+	if f.opts.Policy == Block {
+		select {
+		case f.ch <- msg:
+		case <-f.done:
+		}
+		return nil
+	}
+	select {
+	case f.ch <- msg:
+		return nil
+	default:
+	}
+
+	switch f.opts.Policy {
+	case DropOldest:
+		for {
+			select {
+			case <-f.ch:
+			default:
+				// unbuffered and nobody reading: the new notification is the oldest
+				f.drop()
+				return nil
+			}
+			f.drop()
+			select {
+			case f.ch <- msg:
+				return nil
+			default:
+			}
+		}
+	case Disconnect:
+		f.drop()
+		if f.evicted {
+			return nil
+		}
+		f.evicted = true
+		if f.opts.evict == nil {
+			return f.close
+		}
+		return f.opts.evict
+	default:
+		f.drop()
+		return nil
+	}
+}
+
+func (f *feed[T]) drop() {
+	atomic.AddUint64(f.opts.dropped, 1)
+}
+
+// close closes the channel, releasing a pending send first. It can be called more than once.
+func (f *feed[T]) close() {
+	f.once.Do(func() {
+		close(f.done)
+		f.mu.Lock()
+		close(f.ch)
+		f.mu.Unlock()
+	})
+}
+
+// openFeed returns the feed of key, creating it with opts if needed; created
+// reports whether it did. The lock guarding feeds must be held.
+func openFeed[K comparable, T any](feeds map[K]*feed[T], key K, opts feedOptions) (f *feed[T], created bool) {
+	if f = feeds[key]; f == nil {
+		f = newFeed[T](opts)
+		feeds[key] = f
+		created = true
+	}
+	return
+}
+
+// closeFeed closes and forgets the feed of key, if any. If f is not nil, the
+// feed is closed only if it is still f. The lock guarding feeds must be held.
+func closeFeed[K comparable, T any](feeds map[K]*feed[T], key K, f *feed[T]) {
+	current := feeds[key]
+	if current == nil || (f != nil && current != f) {
+		return
+	}
+	delete(feeds, key)
+	current.close()
+}
+
+// closeAll closes every feed of feeds.
+func closeAll[K comparable, T any](feeds map[K]*feed[T]) {
+	for key, f := range feeds {
+		delete(feeds, key)
+		f.close()
+	}
+}
@@ -0,0 +1,93 @@
+package hitbtc_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// publishTickers sends n ETHBTC tickers, with asks 1 to n, and waits until the
+// client has handled them.
+func publishTickers(t *testing.T, n int, handled func() bool) {
+	for i := 1; i <= n; i++ {
+		server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.NewFromInt(int64(i)), Timestamp: time.Now().UTC()})
+	}
+	require.Eventually(t, handled, 5*time.Second, 10*time.Millisecond)
+}
+
+func asks(feed <-chan hitbtc.WSNotificationTickerResponse, n int) []string {
+	var asks []string
+	for i := 0; i < n; i++ {
+		asks = append(asks, (<-feed).Ask.String())
+	}
+	return asks
+}
+
+func TestFeedDropOldest(t *testing.T) {
+	defer server.Reset()
+	client, err := hitbtc.NewWSClient(hitbtc.WithWSURL(server.WSURL), hitbtc.WithWSFeed(hitbtc.FeedTicker, hitbtc.FeedConfig{Buffer: 2, Policy: hitbtc.DropOldest}))
+	require.NoError(t, err, defaultErrorMessage)
+	defer client.Close()
+
+	feed, err := client.SubscribeTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	publishTickers(t, 5, func() bool { return client.Dropped()["ticker:ETHBTC"] == 3 })
+	require.Equal(t, []string{"4", "5"}, asks(feed, 2))
+}
+
+func TestFeedDropNewest(t *testing.T) {
+	defer server.Reset()
+	client, err := hitbtc.NewWSClient(hitbtc.WithWSURL(server.WSURL), hitbtc.WithWSFeeds(hitbtc.FeedConfig{Buffer: 2, Policy: hitbtc.DropNewest}))
+	require.NoError(t, err, defaultErrorMessage)
+	defer client.Close()
+
+	feed, err := client.SubscribeTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	publishTickers(t, 5, func() bool { return client.Dropped()["ticker:ETHBTC"] == 3 })
+	require.Equal(t, []string{"1", "2"}, asks(feed, 2))
+}
+
+func TestFeedDisconnect(t *testing.T) {
+	defer server.Reset()
+	client, err := hitbtc.NewWSClient(hitbtc.WithWSURL(server.WSURL), hitbtc.WithWSFeed(hitbtc.FeedTicker, hitbtc.FeedConfig{Buffer: 1, Policy: hitbtc.Disconnect}))
+	require.NoError(t, err, defaultErrorMessage)
+	defer client.Close()
+
+	feed, err := client.SubscribeTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	publishTickers(t, 2, func() bool { return client.Dropped()["ticker:ETHBTC"] == 1 })
+
+	select {
+	case err := <-client.Errors():
+		require.True(t, errors.Is(err, hitbtc.ErrSlowConsumer), "got %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no slow consumer error received")
+	}
+	require.Equal(t, []string{"1"}, asks(feed, 1))
+	_, ok := <-feed
+	require.False(t, ok, "the feed should be closed")
+}
+
+func TestFeedErrorsNeverBlock(t *testing.T) {
+	client := newWSClient(t)
+	defer client.Close()
+
+	feed, err := client.SubscribeTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+
+	// undecodable notifications fill the unread errors channel
+	for i := 0; i < 50; i++ {
+		server.Notify("ticker", "garbage")
+	}
+	server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.NewFromInt(7), Timestamp: time.Now().UTC()})
+	select {
+	case ticker := <-feed:
+		require.Equal(t, "7", ticker.Ask.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the ticker was not delivered")
+	}
+}
@@ -2,38 +2,58 @@
 package hitbtc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 const (
-	API_BASE = "https://api.hitbtc.com/api/2" // HitBtc API endpoint
+	API_BASE         = "https://api.hitbtc.com/api/2"       // HitBtc API endpoint
+	WS_API_BASE      = "wss://api.hitbtc.com/api/2/ws"      // HitBtc websocket API endpoint
+	DEMO_API_BASE    = "https://api.demo.hitbtc.com/api/2"  // HitBtc demo API endpoint
+	DEMO_WS_API_BASE = "wss://api.demo.hitbtc.com/api/2/ws" // HitBtc demo websocket API endpoint
+)
+
+// Environment holds the REST and websocket endpoints of a HitBTC compatible exchange.
+type Environment struct {
+	APIBase   string
+	WSAPIBase string
+}
+
+var (
+	// Production is the HitBTC production environment.
+	Production = Environment{APIBase: API_BASE, WSAPIBase: WS_API_BASE}
+	// Demo is the HitBTC demo (sandbox) environment.
+	Demo = Environment{APIBase: DEMO_API_BASE, WSAPIBase: DEMO_WS_API_BASE}
 )
 
-// New returns an instantiated HitBTC struct
-func New(apiKey, apiSecret string) *HitBtc {
-	client := NewClient(apiKey, apiSecret)
-	return &HitBtc{client}
+// New returns an instantiated HitBTC struct configured by opts
+func New(apiKey, apiSecret string, opts ...Option) *HitBtc {
+	client := NewClient(apiKey, apiSecret, opts...)
+	b := &HitBtc{client: client}
+	b.registry = NewRegistry(b)
+	return b
 }
 
 // NewWithCustomHttpClient returns an instantiated HitBTC struct with custom http client
 func NewWithCustomHttpClient(apiKey, apiSecret string, httpClient *http.Client) *HitBtc {
-	client := NewClientWithCustomHttpConfig(apiKey, apiSecret, httpClient)
-	return &HitBtc{client}
+	return New(apiKey, apiSecret, WithHTTPClient(httpClient))
 }
 
 // NewWithCustomTimeout returns an instantiated HitBTC struct with custom timeout
 func NewWithCustomTimeout(apiKey, apiSecret string, timeout time.Duration) *HitBtc {
-	client := NewClientWithCustomTimeout(apiKey, apiSecret, timeout)
-	return &HitBtc{client}
+	return New(apiKey, apiSecret, WithTimeout(timeout))
 }
 
-// handleErr gets JSON response from livecoin API en deal with error
+// handleErr gets JSON response from HitBtc API and turns an error object into an *APIError
 func handleErr(r interface{}) error {
 	switch v := r.(type) {
 	case map[string]interface{}:
@@ -41,8 +61,13 @@ func handleErr(r interface{}) error {
 		if error != nil {
 			switch v := error.(type) {
 			case map[string]interface{}:
-				errorMessage := error.(map[string]interface{})["message"]
-				return errors.New(errorMessage.(string))
+				apiErr := &APIError{}
+				if code, ok := v["code"].(float64); ok {
+					apiErr.Code = int(code)
+				}
+				apiErr.Message, _ = v["message"].(string)
+				apiErr.Description, _ = v["description"].(string)
+				return apiErr
 			default:
 				return fmt.Errorf("I don't know about type %T!\n", v)
 			}
@@ -58,7 +83,14 @@ func handleErr(r interface{}) error {
 
 // HitBtc represent a HitBTC client
 type HitBtc struct {
-	client *client
+	client   *client
+	registry *Registry
+}
+
+// Registry returns the symbol and currency registry of the client, used to
+// normalize orders. It is loaded on first use.
+func (b *HitBtc) Registry() *Registry {
+	return b.registry
 }
 
 // SetDebug sets enable/disable http request/response dump
@@ -66,9 +98,25 @@ func (b *HitBtc) SetDebug(enable bool) {
 	b.client.debug = enable
 }
 
+// SetRateLimiter sets the limiter pacing the requests of the client.
+// A nil limiter disables client-side rate limiting.
+func (b *HitBtc) SetRateLimiter(limiter *RateLimiter) {
+	b.client.limiter = limiter
+}
+
+// SetRetryPolicy sets how idempotent requests are retried on transient failures.
+func (b *HitBtc) SetRetryPolicy(policy RetryPolicy) {
+	b.client.retry = policy
+}
+
 // GetCurrencies is used to get all supported currencies at HitBtc along with other meta data.
 func (b *HitBtc) GetCurrencies() (currencies []Currency, err error) {
-	r, err := b.client.do("GET", "public/currency", nil, false)
+	return b.GetCurrenciesCtx(context.Background())
+}
+
+// GetCurrenciesCtx is like GetCurrencies but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetCurrenciesCtx(ctx context.Context) (currencies []Currency, err error) {
+	r, err := b.client.do(ctx, "GET", "public/currency", nil, false)
 	if err != nil {
 		return
 	}
@@ -85,7 +133,12 @@ func (b *HitBtc) GetCurrencies() (currencies []Currency, err error) {
 
 // GetSymbols is used to get the open and available trading markets at HitBtc along with other meta data.
 func (b *HitBtc) GetSymbols() (symbols []Symbol, err error) {
-	r, err := b.client.do("GET", "public/symbol", nil, false)
+	return b.GetSymbolsCtx(context.Background())
+}
+
+// GetSymbolsCtx is like GetSymbols but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetSymbolsCtx(ctx context.Context) (symbols []Symbol, err error) {
+	r, err := b.client.do(ctx, "GET", "public/symbol", nil, false)
 	if err != nil {
 		return
 	}
@@ -102,7 +155,12 @@ func (b *HitBtc) GetSymbols() (symbols []Symbol, err error) {
 
 // GetTicker is used to get the current ticker values for a market.
 func (b *HitBtc) GetTicker(market string) (ticker Ticker, err error) {
-	r, err := b.client.do("GET", "public/ticker/"+strings.ToUpper(market), nil, false)
+	return b.GetTickerCtx(context.Background(), market)
+}
+
+// GetTickerCtx is like GetTicker but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetTickerCtx(ctx context.Context, market string) (ticker Ticker, err error) {
+	r, err := b.client.do(ctx, "GET", "public/ticker/"+strings.ToUpper(market), nil, false)
 	if err != nil {
 		return
 	}
@@ -119,7 +177,12 @@ func (b *HitBtc) GetTicker(market string) (ticker Ticker, err error) {
 
 // GetAllTicker is used to get the current ticker values for all markets.
 func (b *HitBtc) GetAllTicker() (tickers Tickers, err error) {
-	r, err := b.client.do("GET", "public/ticker", nil, false)
+	return b.GetAllTickerCtx(context.Background())
+}
+
+// GetAllTickerCtx is like GetAllTicker but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetAllTickerCtx(ctx context.Context) (tickers Tickers, err error) {
+	r, err := b.client.do(ctx, "GET", "public/ticker", nil, false)
 	if err != nil {
 		return
 	}
@@ -139,7 +202,88 @@ func (b *HitBtc) GetAllTicker() (tickers Tickers, err error) {
 
 // GetOrderbook is used to get the current order book for a market.
 func (b *HitBtc) GetOrderbook(market string) (orderbook Orderbook, err error) {
-	r, err := b.client.do("GET", "public/orderbook/"+strings.ToUpper(market), nil, false)
+	return b.GetOrderbookCtx(context.Background(), market)
+}
+
+// GetOrderbookCtx is like GetOrderbook but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetOrderbookCtx(ctx context.Context, market string) (orderbook Orderbook, err error) {
+	return b.getOrderbook(ctx, market, nil)
+}
+
+// GetOrderbookDepth is used to get the order book of a market limited to
+// limit price levels on each side. A limit of 0 returns the full book.
+func (b *HitBtc) GetOrderbookDepth(market string, limit int) (orderbook Orderbook, err error) {
+	return b.GetOrderbookDepthCtx(context.Background(), market, limit)
+}
+
+// GetOrderbookDepthCtx is like GetOrderbookDepth but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetOrderbookDepthCtx(ctx context.Context, market string, limit int) (orderbook Orderbook, err error) {
+	return b.getOrderbook(ctx, market, map[string]string{"limit": strconv.Itoa(limit)})
+}
+
+// maxConcurrentOrderbooks bounds the requests in flight in GetOrderbooks.
+const maxConcurrentOrderbooks = 8
+
+// GetOrderbooks is used to get the order books of several markets at once,
+// limited to limit price levels on each side (0 for the full books).
+// The requests are made concurrently and paced by the rate limiter of the client.
+// The first error cancels the remaining requests and is returned.
+func (b *HitBtc) GetOrderbooks(markets []string, limit int) (orderbooks map[string]Orderbook, err error) {
+	return b.GetOrderbooksCtx(context.Background(), markets, limit)
+}
+
+// GetOrderbooksCtx is like GetOrderbooks but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetOrderbooksCtx(ctx context.Context, markets []string, limit int) (orderbooks map[string]Orderbook, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, maxConcurrentOrderbooks)
+	)
+	orderbooks = make(map[string]Orderbook, len(markets))
+	for _, market := range markets {
+		market := strings.ToUpper(market)
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			orderbook, e := b.GetOrderbookDepthCtx(ctx, market, limit)
+			mu.Lock()
+			defer mu.Unlock()
+			if e != nil {
+				if err == nil {
+					err = fmt.Errorf("orderbook of %s: %w", market, e)
+					cancel()
+				}
+				return
+			}
+			orderbooks[market] = orderbook
+		}()
+	}
+	wg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		orderbooks = nil
+	}
+	return
+}
+
+// getOrderbook fetches the order book of market with the given query parameters.
+func (b *HitBtc) getOrderbook(ctx context.Context, market string, payload map[string]string) (orderbook Orderbook, err error) {
+	r, err := b.client.do(ctx, "GET", "public/orderbook/"+strings.ToUpper(market), payload, false)
 	if err != nil {
 		return
 	}
@@ -154,12 +298,101 @@ func (b *HitBtc) GetOrderbook(market string) (orderbook Orderbook, err error) {
 	return
 }
 
+// maxPageSize is the largest limit accepted by the paginated endpoints.
+const maxPageSize = 1000
+
+// formatTime formats t as expected by the from and till parameters.
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.999Z")
+}
+
+// GetCandles is used to get the candles of a market, oldest first.
+// Zero from and till times are not sent. A limit above 1000 is fetched in
+// several pages; 0 uses the API default of 100 candles.
+func (b *HitBtc) GetCandles(market string, period Period, from, till time.Time, limit int) (candles []Candle, err error) {
+	return b.GetCandlesCtx(context.Background(), market, period, from, till, limit)
+}
+
+// GetCandlesCtx is like GetCandles but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetCandlesCtx(ctx context.Context, market string, period Period, from, till time.Time, limit int) (candles []Candle, err error) {
+	payload := map[string]string{"sort": "ASC"}
+	if period != "" {
+		payload["period"] = string(period)
+	}
+	if !from.IsZero() {
+		payload["from"] = formatTime(from)
+	}
+	if !till.IsZero() {
+		payload["till"] = formatTime(till)
+	}
+	candles = []Candle{}
+	for {
+		pageSize := limit - len(candles)
+		if pageSize > maxPageSize {
+			pageSize = maxPageSize
+		}
+		if pageSize > 0 {
+			payload["limit"] = strconv.Itoa(pageSize)
+		}
+		if len(candles) > 0 {
+			payload["offset"] = strconv.Itoa(len(candles))
+		}
+		var r []byte
+		r, err = b.client.do(ctx, "GET", "public/candles/"+strings.ToUpper(market), payload, false)
+		if err != nil {
+			return
+		}
+		var response interface{}
+		if err = json.Unmarshal(r, &response); err != nil {
+			return
+		}
+		if err = handleErr(response); err != nil {
+			return
+		}
+		var page []Candle
+		if err = json.Unmarshal(r, &page); err != nil {
+			return
+		}
+		candles = append(candles, page...)
+		if pageSize <= 0 || len(page) < pageSize || len(candles) >= limit {
+			return
+		}
+	}
+}
+
+// GetPublicTrades is used to get the trades made on a market.
+func (b *HitBtc) GetPublicTrades(market string, query TradesQuery) (trades []PublicTrade, err error) {
+	return b.GetPublicTradesCtx(context.Background(), market, query)
+}
+
+// GetPublicTradesCtx is like GetPublicTrades but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetPublicTradesCtx(ctx context.Context, market string, query TradesQuery) (trades []PublicTrade, err error) {
+	r, err := b.client.do(ctx, "GET", "public/trades/"+strings.ToUpper(market), query.payload(), false)
+	if err != nil {
+		return
+	}
+	var response interface{}
+	if err = json.Unmarshal(r, &response); err != nil {
+		return
+	}
+	if err = handleErr(response); err != nil {
+		return
+	}
+	err = json.Unmarshal(r, &trades)
+	return
+}
+
 
 // Account
 
 // GetBalances is used to retrieve all balances from your account
 func (b *HitBtc) GetBalances() (balances []Balance, err error) {
-	r, err := b.client.do("GET", "trading/balance", nil, true)
+	return b.GetBalancesCtx(context.Background())
+}
+
+// GetBalancesCtx is like GetBalances but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetBalancesCtx(ctx context.Context) (balances []Balance, err error) {
+	r, err := b.client.do(ctx, "GET", "trading/balance", nil, true)
 	if err != nil {
 		return
 	}
@@ -177,7 +410,15 @@ func (b *HitBtc) GetBalances() (balances []Balance, err error) {
 // GetBalance is used to retrieve the balance from your account for a specific currency.
 // currency: a string literal for the currency (ex: LTC)
 func (b *HitBtc) GetBalance(currency string) (balance Balance, err error) {
-	balances, err := b.GetBalances()
+	return b.GetBalanceCtx(context.Background(), currency)
+}
+
+// GetBalanceCtx is like GetBalance but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetBalanceCtx(ctx context.Context, currency string) (balance Balance, err error) {
+	balances, err := b.GetBalancesCtx(ctx)
+	if err != nil {
+		return
+	}
 	currency = strings.ToUpper(currency)
 
 	for _, balance = range balances {
@@ -192,11 +433,16 @@ func (b *HitBtc) GetBalance(currency string) (balance Balance, err error) {
 // GetTrades used to retrieve your trade history.
 // market string literal for the market (ie. BTC/LTC). If set to "all", will return for all market
 func (b *HitBtc) GetTrades(currencyPair string) (trades []Trade, err error) {
+	return b.GetTradesCtx(context.Background(), currencyPair)
+}
+
+// GetTradesCtx is like GetTrades but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetTradesCtx(ctx context.Context, currencyPair string) (trades []Trade, err error) {
 	payload := make(map[string]string)
 	if currencyPair != "all" {
 		payload["symbol"] = currencyPair
 	}
-	r, err := b.client.do("GET", "history/trades", payload, true)
+	r, err := b.client.do(ctx, "GET", "history/trades", payload, true)
 	if err != nil {
 		return
 	}
@@ -213,11 +459,16 @@ func (b *HitBtc) GetTrades(currencyPair string) (trades []Trade, err error) {
 
 // CancelOrder cancels a pending order
 func (b *HitBtc) CancelOrder(currencyPair string) (orders []Order, err error) {
+	return b.CancelOrderCtx(context.Background(), currencyPair)
+}
+
+// CancelOrderCtx is like CancelOrder but honors the cancellation and deadline of ctx.
+func (b *HitBtc) CancelOrderCtx(ctx context.Context, currencyPair string) (orders []Order, err error) {
 	payload := make(map[string]string)
 	if currencyPair != "all" {
 		payload["symbol"] = currencyPair
 	}
-	r, err := b.client.do("DELETE", "order", payload, true)
+	r, err := b.client.do(ctx, "DELETE", "order", payload, true)
 	if err != nil {
 		return
 	}
@@ -234,9 +485,14 @@ func (b *HitBtc) CancelOrder(currencyPair string) (orders []Order, err error) {
 
 // GetOrder gets a pending order data.
 func (b *HitBtc) GetOrder(orderId string) (orders []Order, err error) {
+	return b.GetOrderCtx(context.Background(), orderId)
+}
+
+// GetOrderCtx is like GetOrder but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetOrderCtx(ctx context.Context, orderId string) (orders []Order, err error) {
 	payload := make(map[string]string)
 	payload["clientOrderId"] = orderId
-	r, err := b.client.do("GET", "history/order", payload, true)
+	r, err := b.client.do(ctx, "GET", "history/order", payload, true)
 	if err != nil {
 		return
 	}
@@ -253,7 +509,12 @@ func (b *HitBtc) GetOrder(orderId string) (orders []Order, err error) {
 
 // GetOrderHistory gets the history of orders for an user.
 func (b *HitBtc) GetOrderHistory() (orders []Order, err error) {
-	r, err := b.client.do("GET", "history/order", nil, true)
+	return b.GetOrderHistoryCtx(context.Background())
+}
+
+// GetOrderHistoryCtx is like GetOrderHistory but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetOrderHistoryCtx(ctx context.Context) (orders []Order, err error) {
+	r, err := b.client.do(ctx, "GET", "history/order", nil, true)
 	if err != nil {
 		return
 	}
@@ -270,7 +531,12 @@ func (b *HitBtc) GetOrderHistory() (orders []Order, err error) {
 
 // GetOpenOrders gets the open orders of an user.
 func (b *HitBtc) GetOpenOrders() (orders []Order, err error) {
-	r, err := b.client.do("GET", "order", nil, true)
+	return b.GetOpenOrdersCtx(context.Background())
+}
+
+// GetOpenOrdersCtx is like GetOpenOrders but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetOpenOrdersCtx(ctx context.Context) (orders []Order, err error) {
+	r, err := b.client.do(ctx, "GET", "order", nil, true)
 	if err != nil {
 		return
 	}
@@ -286,15 +552,36 @@ func (b *HitBtc) GetOpenOrders() (orders []Order, err error) {
 }
 
 // PlaceOrder creates a new order.
+// With WithOrderNormalization, the order is validated and rounded to its symbol steps first.
 func (b *HitBtc) PlaceOrder(requestOrder Order) (responseOrder Order, err error) {
+	return b.PlaceOrderCtx(context.Background(), requestOrder)
+}
+
+// PlaceOrderCtx is like PlaceOrder but honors the cancellation and deadline of ctx.
+func (b *HitBtc) PlaceOrderCtx(ctx context.Context, requestOrder Order) (responseOrder Order, err error) {
+	if b.client.normalization != nil {
+		var symbol Symbol
+		if symbol, err = b.registry.lookupSymbol(ctx, requestOrder.Symbol); err != nil {
+			return
+		}
+		if requestOrder, err = symbol.NormalizeOrder(requestOrder, *b.client.normalization); err != nil {
+			return
+		}
+	}
+
 	payload := make(map[string]string, 6)
 
 	payload["symbol"] = requestOrder.Symbol
 	payload["side"] = requestOrder.Side
 	payload["type"] = requestOrder.Type
 	payload["timeInForce"] = requestOrder.TimeInForce
-	payload["quantity"] = fmt.Sprintf("%.8f", requestOrder.Quantity)
-	payload["price"] = fmt.Sprintf("%.8f", requestOrder.Price)
+	payload["quantity"] = requestOrder.Quantity.String()
+	if !requestOrder.Price.IsZero() {
+		payload["price"] = requestOrder.Price.String()
+	}
+	if !requestOrder.StopPrice.IsZero() {
+		payload["stopPrice"] = requestOrder.StopPrice.String()
+	}
 
 	method := "POST"
 	resource := "order"
@@ -304,7 +591,7 @@ func (b *HitBtc) PlaceOrder(requestOrder Order) (responseOrder Order, err error)
 		resource = fmt.Sprintf("%s/%s", resource, requestOrder.ClientOrderId)
 	}
 
-	r, err := b.client.do(method, resource, payload, true)
+	r, err := b.client.do(ctx, method, resource, payload, true)
 	if err != nil {
 		return
 	}
@@ -322,6 +609,11 @@ func (b *HitBtc) PlaceOrder(requestOrder Order) (responseOrder Order, err error)
 // GetTransactions is used to retrieve your withdrawal and deposit history
 // "Start" and "end" are given in UNIX timestamp format in miliseconds and used to specify the date range for the data returned.
 func (b *HitBtc) GetTransactions(start uint64, end uint64, limit uint32) (transactions []Transaction, err error) {
+	return b.GetTransactionsCtx(context.Background(), start, end, limit)
+}
+
+// GetTransactionsCtx is like GetTransactions but honors the cancellation and deadline of ctx.
+func (b *HitBtc) GetTransactionsCtx(ctx context.Context, start uint64, end uint64, limit uint32) (transactions []Transaction, err error) {
 	payload := make(map[string]string)
 	if start > 0 {
 		payload["from"] = strconv.FormatUint(uint64(start), 10)
@@ -338,7 +630,7 @@ func (b *HitBtc) GetTransactions(start uint64, end uint64, limit uint32) (transa
 	if limit > 0 {
 		payload["limit"] = strconv.FormatUint(uint64(limit), 10)
 	}
-	r, err := b.client.do("GET", "account/transactions", payload, true)
+	r, err := b.client.do(ctx, "GET", "account/transactions", payload, true)
 	if err != nil {
 		return
 	}
@@ -354,7 +646,12 @@ func (b *HitBtc) GetTransactions(start uint64, end uint64, limit uint32) (transa
 }
 
 // Withdraw performs a withdrawal operation.
-func (b *HitBtc) Withdraw(address string, currency string, amount float64) (withdrawID string, err error) {
+func (b *HitBtc) Withdraw(address string, currency string, amount decimal.Decimal) (withdrawID string, err error) {
+	return b.WithdrawCtx(context.Background(), address, currency, amount)
+}
+
+// WithdrawCtx is like Withdraw but honors the cancellation and deadline of ctx.
+func (b *HitBtc) WithdrawCtx(ctx context.Context, address string, currency string, amount decimal.Decimal) (withdrawID string, err error) {
 	type withdrawResponse struct {
 		ID string `json:"id,required"`
 	}
@@ -362,10 +659,10 @@ func (b *HitBtc) Withdraw(address string, currency string, amount float64) (with
 	payload := map[string]string{
 		"currency": currency,
 		"address":  address,
-		"amount":   fmt.Sprint(amount),
+		"amount":   amount.String(),
 	}
 
-	r, err := b.client.do("POST", "account/crypto/withdraw", payload, true)
+	r, err := b.client.do(ctx, "POST", "account/crypto/withdraw", payload, true)
 	if err != nil {
 		return
 	}
@@ -395,18 +692,23 @@ const (
 )
 
 // TransferBalance performs a balance transfer operation between trading and bank accounts (both directions).
-func (b *HitBtc) TransferBalance(currency string, amount float64, transferType transferType) (transferID string, err error) {
+func (b *HitBtc) TransferBalance(currency string, amount decimal.Decimal, transferType transferType) (transferID string, err error) {
+	return b.TransferBalanceCtx(context.Background(), currency, amount, transferType)
+}
+
+// TransferBalanceCtx is like TransferBalance but honors the cancellation and deadline of ctx.
+func (b *HitBtc) TransferBalanceCtx(ctx context.Context, currency string, amount decimal.Decimal, transferType transferType) (transferID string, err error) {
 	type transferResponse struct {
 		ID string `json:"id,required"`
 	}
 
 	payload := map[string]string{
 		"currency": currency,
-		"amount":   fmt.Sprint(amount),
+		"amount":   amount.String(),
 		"type":     string(transferType),
 	}
 
-	r, err := b.client.do("POST", "account/transfer", payload, true)
+	r, err := b.client.do(ctx, "POST", "account/transfer", payload, true)
 	if err != nil {
 		return
 	}
@@ -1,24 +1,39 @@
 package hitbtc_test
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/bitbandi/go-hitbtc/hitbtctest"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
 const (
-	// email: g3217755@nwytg.net
-	// password: Test10
 	apiKey    = "7567417ba8df166f50584b54ccd924c5"
 	apiSecret = "2203d97807b478326c4bcade686538b0"
 )
 
 var (
-	hitBtc              *hitbtc.HitBtc = hitbtc.New(apiKey, apiSecret)
-	defaultErrorMessage string         = "There should be no error"
+	server              *hitbtctest.Server
+	hitBtc              *hitbtc.HitBtc
+	defaultErrorMessage string = "There should be no error"
 )
 
+func TestMain(m *testing.M) {
+	server = hitbtctest.NewServer(apiKey, apiSecret)
+	hitBtc = hitbtc.New(apiKey, apiSecret, hitbtc.WithBaseURL(server.URL))
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
+
 func TestGetCurrencies(t *testing.T) {
 	currencies, err := hitBtc.GetCurrencies()
 	t.Logf("GetCurrencies : %#v\n", currencies)
@@ -37,12 +52,110 @@ func TestGetTicker(t *testing.T) {
 	require.NoError(t, err, defaultErrorMessage)
 }
 
+func TestGetTickerCtx(t *testing.T) {
+	// a server never answering, until the client goes away
+	blocking := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer blocking.Close()
+	client := hitbtc.New(apiKey, apiSecret, hitbtc.WithBaseURL(blocking.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.GetTickerCtx(ctx, "ETHBTC")
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+	require.Less(t, time.Since(start), time.Second, "the deadline is honored")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start = time.Now()
+	_, err = client.GetTickerCtx(ctx, "ETHBTC")
+	require.True(t, errors.Is(err, context.Canceled), "got %v", err)
+	require.Less(t, time.Since(start), time.Second, "the cancellation is honored")
+}
+
 func TestGetOrderbook(t *testing.T) {
 	orderbook, err := hitBtc.GetOrderbook("ETHBTC")
 	t.Logf("GetOrderbook : %#v\n", orderbook)
 	require.NoError(t, err, defaultErrorMessage)
 }
 
+func TestGetOrderbookDepth(t *testing.T) {
+	orderbook, err := hitBtc.GetOrderbookDepth("ETHBTC", 1)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, orderbook.Ask, 1)
+	require.Len(t, orderbook.Bid, 1)
+	require.False(t, orderbook.Timestamp.IsZero())
+
+	orderbook, err = hitBtc.GetOrderbookDepth("ETHBTC", 0)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, orderbook.Ask, 2)
+}
+
+func TestGetOrderbooks(t *testing.T) {
+	orderbooks, err := hitBtc.GetOrderbooks([]string{"ETHBTC", "btcusd"}, 1)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, orderbooks, 2)
+	require.Equal(t, "30001", orderbooks["BTCUSD"].Ask[0].Price.String())
+	require.False(t, orderbooks["ETHBTC"].Timestamp.IsZero())
+
+	_, err = hitBtc.GetOrderbooks([]string{"ETHBTC", "NOPE", "BTCUSD"}, 0)
+	require.Error(t, err)
+}
+
+func TestGetCandles(t *testing.T) {
+	defer server.Reset()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := make([]hitbtc.Candle, 1500)
+	for i := range candles {
+		candles[i] = hitbtc.Candle{Timestamp: start.Add(time.Duration(i) * time.Minute), Open: decimal.NewFromInt(int64(i))}
+	}
+	server.SetCandles("ETHBTC", hitbtc.PeriodM1, candles)
+
+	got, err := hitBtc.GetCandles("ETHBTC", hitbtc.PeriodM1, start.Add(100*time.Minute), time.Time{}, 1200)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, got, 1200)
+	require.Equal(t, "100", got[0].Open.String())
+	require.Equal(t, "1299", got[1199].Open.String())
+	require.True(t, got[1199].Timestamp.Equal(start.Add(1299*time.Minute)))
+
+	got, err = hitBtc.GetCandles("ETHBTC", hitbtc.PeriodM1, time.Time{}, start.Add(9*time.Minute), 0)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, got, 10)
+
+	_, err = hitBtc.GetCandles("NOPE", hitbtc.PeriodM1, time.Time{}, time.Time{}, 0)
+	require.Error(t, err)
+}
+
+func TestGetPublicTrades(t *testing.T) {
+	defer server.Reset()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 10; i++ {
+		server.AddPublicTrades("ETHBTC", hitbtc.PublicTrade{Id: uint64(i), Price: decimal.RequireFromString("0.07"), Quantity: decimal.NewFromInt(int64(i)), Side: "buy", Timestamp: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	trades, err := hitBtc.GetPublicTrades("ETHBTC", hitbtc.TradesQuery{})
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, trades, 10)
+	require.Equal(t, uint64(10), trades[0].Id, "newest first by default")
+	require.Equal(t, "buy", trades[0].Side)
+	require.True(t, trades[0].Timestamp.Equal(start.Add(10*time.Second)))
+
+	trades, err = hitBtc.GetPublicTrades("ETHBTC", hitbtc.TradesQuery{Sort: hitbtc.SortAsc, From: start.Add(3 * time.Second), Offset: 1, Limit: 2})
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, trades, 2)
+	require.Equal(t, uint64(4), trades[0].Id)
+	require.Equal(t, uint64(5), trades[1].Id)
+
+	trades, err = hitBtc.GetPublicTrades("ETHBTC", hitbtc.TradesQuery{Sort: hitbtc.SortAsc, By: hitbtc.TradesByID, FromID: 8})
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, trades, 3)
+	require.Equal(t, "0.56", trades[0].Value().String())
+}
+
 func TestGetAllTicker(t *testing.T) {
 	tickers, err := hitBtc.GetAllTicker()
 	t.Logf("GetAllTicker : %v\n", tickers)
@@ -55,6 +168,18 @@ func TestGetBalances(t *testing.T) {
 	require.NoError(t, err, defaultErrorMessage)
 }
 
+func TestGetBalanceDecimalPrecision(t *testing.T) {
+	defer server.Reset()
+
+	available := decimal.RequireFromString("1234.123456789012345678")
+	server.SetBalance(hitbtc.Balance{Currency: "ETH", Available: available, Reserved: decimal.RequireFromString("0.000000000000000001")})
+
+	balance, err := hitBtc.GetBalance("ETH")
+	require.NoError(t, err, defaultErrorMessage)
+	require.True(t, available.Equal(balance.Available), "got %s", balance.Available)
+	require.Equal(t, "1234.123456789012345679", balance.Total().String())
+}
+
 func TestGetTrades(t *testing.T) {
 	trades, err := hitBtc.GetTrades("ETHBTC")
 	t.Logf("GetTrades : %#v\n", trades)
@@ -84,3 +209,56 @@ func TestGetOpenOrders(t *testing.T) {
 	t.Logf("GetOpenOrders : %#v\n", orders)
 	require.NoError(t, err, defaultErrorMessage)
 }
+
+func TestPlaceOrder(t *testing.T) {
+	defer server.Reset()
+
+	order, err := hitBtc.PlaceOrder(hitbtc.Order{Symbol: "ETHBTC", Side: "buy", Type: "limit", TimeInForce: "GTC", Quantity: decimal.NewFromInt(1), Price: decimal.RequireFromString("0.07")})
+	t.Logf("PlaceOrder : %#v\n", order)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, "new", order.Status)
+
+	orders, err := hitBtc.GetOpenOrders()
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, orders, 1)
+
+	_, err = hitBtc.PlaceOrder(hitbtc.Order{Symbol: "ETHBTC", Side: "buy", Type: "limit", Quantity: decimal.NewFromInt(1000), Price: decimal.RequireFromString("0.07")})
+	require.True(t, hitbtc.IsInsufficientFunds(err), "expected insufficient funds, got %v", err)
+}
+
+func TestPlaceOrderLostResponse(t *testing.T) {
+	defer server.Reset()
+	client := hitbtc.New(apiKey, apiSecret, hitbtc.WithBaseURL(server.URL), hitbtc.WithRetryPolicy(hitbtc.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+
+	// the order is placed, but the gateway loses the response: the retry is a duplicate
+	server.LoseNextResponse("PUT", "order/lost-response", hitbtc.APIError{Code: 500, Message: "Bad gateway"})
+	order, err := client.PlaceOrder(hitbtc.Order{ClientOrderId: "lost-response", Symbol: "ETHBTC", Side: "buy", Type: "limit", Quantity: decimal.NewFromInt(1), Price: decimal.RequireFromString("0.07")})
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, "lost-response", order.ClientOrderId)
+	require.Equal(t, "new", order.Status)
+
+	orders, err := client.GetOpenOrders()
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, orders, 1, "the order is placed once")
+
+	_, err = client.PlaceOrder(hitbtc.Order{ClientOrderId: "lost-response", Symbol: "ETHBTC", Side: "buy", Type: "limit", Quantity: decimal.NewFromInt(1), Price: decimal.RequireFromString("0.07")})
+	var apiErr *hitbtc.APIError
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	require.Equal(t, hitbtc.ErrCodeDuplicateClientOrderId, apiErr.Code, "a first attempt is not mistaken for a retry")
+}
+
+func TestAuthFailure(t *testing.T) {
+	client := hitbtc.New(apiKey, "wrong", hitbtc.WithBaseURL(server.URL))
+	_, err := client.GetBalances()
+	require.True(t, hitbtc.IsAuthError(err), "expected auth error, got %v", err)
+}
+
+func TestInjectedError(t *testing.T) {
+	server.FailNext("GET", "public/ticker/ETHBTC", hitbtc.APIError{HTTPStatus: 429, Code: hitbtc.ErrCodeRateLimited, Message: "Too many requests"})
+	client := hitbtc.New(apiKey, apiSecret, hitbtc.WithBaseURL(server.URL), hitbtc.WithRetryPolicy(hitbtc.RetryPolicy{}))
+	_, err := client.GetTicker("ETHBTC")
+	require.True(t, hitbtc.IsRateLimited(err), "expected rate limit error, got %v", err)
+
+	_, err = client.GetTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+}
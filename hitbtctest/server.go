@@ -0,0 +1,781 @@
+// Package hitbtctest provides an in-process fake of the HitBTC REST and
+// websocket APIs, for tests that must run without network access.
+//
+// The fake keeps scriptable state (currencies, symbols, tickers, order books,
+// balances, orders, trades and transactions) and can inject API errors:
+//
+//	srv := hitbtctest.NewServer("key", "secret")
+//	defer srv.Close()
+//
+//	client := hitbtc.New("key", "secret", hitbtc.WithBaseURL(srv.URL))
+//	ws, err := hitbtc.NewWSClient(hitbtc.WithWSURL(srv.WSURL))
+package hitbtctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	apiPath = "/api/2"
+	wsPath  = "/api/2/ws"
+)
+
+// Server is a fake HitBTC server.
+type Server struct {
+	URL       string // base URL of the REST API, to be used with hitbtc.WithBaseURL
+	WSURL     string // URL of the websocket API, to be used with hitbtc.WithWSURL
+	APIKey    string
+	APISecret string
+
+	server *httptest.Server
+
+	mu           sync.Mutex
+	currencies   []hitbtc.Currency
+	symbols      []hitbtc.Symbol
+	tickers      map[string]hitbtc.Ticker
+	orderbooks   map[string]hitbtc.Orderbook
+	candles      map[string][]hitbtc.Candle      // by symbol:period, oldest first
+	publicTrades map[string][]hitbtc.PublicTrade // by symbol, oldest first
+	balances     map[string]hitbtc.Balance
+	orders       []hitbtc.Order       // active orders
+	history      []hitbtc.Order       // closed orders, most recent first
+	trades       []hitbtc.Trade       // oldest first
+	transactions []hitbtc.Transaction // oldest first
+	failures     map[string][]*hitbtc.APIError
+	lost         map[string][]*hitbtc.APIError // failures answered after handling the request
+	nextID       uint64
+
+	ws wsState
+}
+
+// NewServer starts a fake server accepting the given credentials,
+// preloaded with a small BTC/ETH/USD market (see Reset).
+func NewServer(apiKey, apiSecret string) *Server {
+	s := &Server{APIKey: apiKey, APISecret: apiSecret}
+	s.Reset()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(wsPath, s.serveWS)
+	mux.HandleFunc(apiPath+"/", s.serveREST)
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL + apiPath
+	s.WSURL = "ws" + strings.TrimPrefix(s.server.URL, "http") + wsPath
+	return s
+}
+
+// Close shuts the server and all its websocket connections down.
+func (s *Server) Close() {
+	s.closeWS()
+	s.server.Close()
+}
+
+// Reset restores the default market: BTC, ETH and USD currencies,
+// ETHBTC and BTCUSD symbols with their tickers and order books,
+// and a balance of 10 BTC, 100 ETH and 100000 USD.
+func (s *Server) Reset() {
+	timestamp := now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currencies = []hitbtc.Currency{
+		{Id: "BTC", FullName: "Bitcoin", Crypto: true, PayinEnabled: true, PayoutEnabled: true, TransferEnabled: true, PayinConfirmations: 2},
+		{Id: "ETH", FullName: "Ethereum", Crypto: true, PayinEnabled: true, PayoutEnabled: true, TransferEnabled: true, PayinConfirmations: 2},
+		{Id: "USD", FullName: "US Dollar", PayinEnabled: true, PayoutEnabled: true, TransferEnabled: true},
+	}
+	s.symbols = []hitbtc.Symbol{
+		{Id: "ETHBTC", BaseCurrency: "ETH", QuoteCurrency: "BTC", QuantityIncrement: d("0.001"), TickSize: d("0.000001"), TakeLiquidityRate: d("0.001"), ProvideLiquidityRate: d("-0.0001"), FeeCurrency: "BTC"},
+		{Id: "BTCUSD", BaseCurrency: "BTC", QuoteCurrency: "USD", QuantityIncrement: d("0.00001"), TickSize: d("0.01"), TakeLiquidityRate: d("0.001"), ProvideLiquidityRate: d("-0.0001"), FeeCurrency: "USD"},
+	}
+	s.tickers = map[string]hitbtc.Ticker{
+		"ETHBTC": {Symbol: "ETHBTC", Ask: d("0.0701"), Bid: d("0.07"), Last: d("0.0701"), Open: d("0.069"), Low: d("0.0685"), High: d("0.071"), Volume: d("1200"), VolumeQuote: d("84"), Timestamp: timestamp},
+		"BTCUSD": {Symbol: "BTCUSD", Ask: d("30001"), Bid: d("30000"), Last: d("30000.5"), Open: d("29500"), Low: d("29400"), High: d("30100"), Volume: d("350"), VolumeQuote: d("10500000"), Timestamp: timestamp},
+	}
+	s.orderbooks = map[string]hitbtc.Orderbook{
+		"ETHBTC": {
+			Ask: []hitbtc.OrderBookItem{{Price: d("0.0701"), Size: d("5")}, {Price: d("0.0702"), Size: d("10")}},
+			Bid: []hitbtc.OrderBookItem{{Price: d("0.07"), Size: d("4")}, {Price: d("0.0699"), Size: d("12")}},
+		},
+		"BTCUSD": {
+			Ask: []hitbtc.OrderBookItem{{Price: d("30001"), Size: d("0.5")}, {Price: d("30002"), Size: d("1")}},
+			Bid: []hitbtc.OrderBookItem{{Price: d("30000"), Size: d("0.4")}, {Price: d("29999"), Size: d("2")}},
+		},
+	}
+	s.candles = make(map[string][]hitbtc.Candle)
+	s.publicTrades = make(map[string][]hitbtc.PublicTrade)
+	s.balances = map[string]hitbtc.Balance{
+		"BTC": {Currency: "BTC", Available: d("10")},
+		"ETH": {Currency: "ETH", Available: d("100")},
+		"USD": {Currency: "USD", Available: d("100000")},
+	}
+	s.orders = nil
+	s.history = nil
+	s.trades = nil
+	s.transactions = nil
+	s.failures = make(map[string][]*hitbtc.APIError)
+	s.lost = make(map[string][]*hitbtc.APIError)
+}
+
+// SetCurrencies replaces the listed currencies.
+func (s *Server) SetCurrencies(currencies []hitbtc.Currency) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currencies = append([]hitbtc.Currency(nil), currencies...)
+}
+
+// SetSymbols replaces the listed symbols.
+func (s *Server) SetSymbols(symbols []hitbtc.Symbol) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symbols = append([]hitbtc.Symbol(nil), symbols...)
+}
+
+// SetTicker sets the ticker of ticker.Symbol.
+func (s *Server) SetTicker(ticker hitbtc.Ticker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tickers[ticker.Symbol] = ticker
+}
+
+// SetOrderbook sets the order book of symbol.
+func (s *Server) SetOrderbook(symbol string, orderbook hitbtc.Orderbook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orderbooks[symbol] = orderbook
+}
+
+// SetBalance sets the trading balance of balance.Currency.
+func (s *Server) SetBalance(balance hitbtc.Balance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balances[balance.Currency] = balance
+}
+
+// Balance returns the trading balance of currency.
+func (s *Server) Balance(currency string) hitbtc.Balance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.balances[currency]
+}
+
+// Orders returns the active orders.
+func (s *Server) Orders() []hitbtc.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]hitbtc.Order(nil), s.orders...)
+}
+
+// SetCandles replaces the candles of symbol for period. They are sorted oldest first.
+func (s *Server) SetCandles(symbol string, period hitbtc.Period, candles []hitbtc.Candle) {
+	candles = append([]hitbtc.Candle(nil), candles...)
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Timestamp.Before(candles[j].Timestamp) })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.candles[candleKey(symbol, period)] = candles
+}
+
+// AddPublicTrades appends trades to the market trades of symbol.
+func (s *Server) AddPublicTrades(symbol string, trades ...hitbtc.PublicTrade) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.publicTrades[symbol] = append(s.publicTrades[symbol], trades...)
+	sort.SliceStable(s.publicTrades[symbol], func(i, j int) bool {
+		return s.publicTrades[symbol][i].Timestamp.Before(s.publicTrades[symbol][j].Timestamp)
+	})
+}
+
+// AddOrderHistory appends closed orders to the order history.
+func (s *Server) AddOrderHistory(orders ...hitbtc.Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, orders...)
+}
+
+// AddTrades appends trades to the trade history.
+func (s *Server) AddTrades(trades ...hitbtc.Trade) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trades = append(s.trades, trades...)
+}
+
+// AddTransactions appends transactions to the account history.
+func (s *Server) AddTransactions(transactions ...hitbtc.Transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transactions = append(s.transactions, transactions...)
+}
+
+// FailNext makes the next request for method and resource (relative to the
+// API base, e.g. "public/ticker/ETHBTC") fail with err. Several failures can
+// be queued; they are consumed one per request.
+// A zero err.HTTPStatus defaults to 400.
+func (s *Server) FailNext(method, resource string, err hitbtc.APIError) {
+	if err.HTTPStatus == 0 {
+		err.HTTPStatus = http.StatusBadRequest
+	}
+	key := method + " " + strings.Trim(resource, "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[key] = append(s.failures[key], &err)
+}
+
+// LoseNextResponse makes the next request for method and resource succeed,
+// but answers err instead of its response, as a failing gateway would.
+// A zero err.HTTPStatus defaults to 502.
+func (s *Server) LoseNextResponse(method, resource string, err hitbtc.APIError) {
+	if err.HTTPStatus == 0 {
+		err.HTTPStatus = http.StatusBadGateway
+	}
+	key := method + " " + strings.Trim(resource, "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lost[key] = append(s.lost[key], &err)
+}
+
+// takeFailure pops the next failure queued for key in failures, a map of the
+// server. s.mu must be held.
+func takeFailure(failures map[string][]*hitbtc.APIError, key string) *hitbtc.APIError {
+	queue := failures[key]
+	if len(queue) == 0 {
+		return nil
+	}
+	failures[key] = queue[1:]
+	return queue[0]
+}
+
+// newID returns a new unique identifier. s.mu must be held.
+func (s *Server) newID() uint64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Server) serveREST(w http.ResponseWriter, r *http.Request) {
+	resource := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPath), "/")
+	form, err := parseForm(r)
+	if err != nil {
+		writeError(w, &hitbtc.APIError{HTTPStatus: http.StatusBadRequest, Code: hitbtc.ErrCodeValidation, Message: "Validation error", Description: err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if failure := takeFailure(s.failures, r.Method+" "+resource); failure != nil {
+		writeError(w, failure)
+		return
+	}
+
+	parts := strings.Split(resource, "/")
+	if parts[0] != "public" {
+		user, pass, ok := r.BasicAuth()
+		if !ok {
+			writeError(w, &hitbtc.APIError{HTTPStatus: http.StatusUnauthorized, Code: hitbtc.ErrCodeAuthRequired, Message: "Authorization required"})
+			return
+		}
+		if user != s.APIKey || pass != s.APISecret {
+			writeError(w, &hitbtc.APIError{HTTPStatus: http.StatusUnauthorized, Code: hitbtc.ErrCodeAuthFailed, Message: "Authorization failed"})
+			return
+		}
+	}
+
+	result, apiErr := s.route(r.Method, parts, form)
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	if failure := takeFailure(s.lost, r.Method+" "+resource); failure != nil {
+		writeError(w, failure)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// route dispatches a REST request. s.mu must be held.
+func (s *Server) route(method string, parts []string, form url.Values) (interface{}, *hitbtc.APIError) {
+	arg := ""
+	if len(parts) > 1 {
+		arg = parts[len(parts)-1]
+	}
+	switch {
+	case method == "GET" && match(parts, "public", "currency"):
+		return s.currencies, nil
+	case method == "GET" && match(parts, "public", "currency", "*"):
+		for _, currency := range s.currencies {
+			if currency.Id == arg {
+				return currency, nil
+			}
+		}
+		return nil, currencyNotFound()
+	case method == "GET" && match(parts, "public", "symbol"):
+		return s.symbols, nil
+	case method == "GET" && match(parts, "public", "symbol", "*"):
+		if symbol, ok := s.symbol(arg); ok {
+			return symbol, nil
+		}
+		return nil, symbolNotFound()
+	case method == "GET" && match(parts, "public", "ticker"):
+		tickers := make([]hitbtc.Ticker, 0, len(s.tickers))
+		for _, ticker := range s.tickers {
+			tickers = append(tickers, ticker)
+		}
+		sort.Slice(tickers, func(i, j int) bool { return tickers[i].Symbol < tickers[j].Symbol })
+		return tickers, nil
+	case method == "GET" && match(parts, "public", "ticker", "*"):
+		if ticker, ok := s.tickers[arg]; ok {
+			return ticker, nil
+		}
+		return nil, symbolNotFound()
+	case method == "GET" && match(parts, "public", "orderbook", "*"):
+		orderbook, ok := s.orderbooks[arg]
+		if !ok {
+			return nil, symbolNotFound()
+		}
+		limit, err := strconv.Atoi(form.Get("limit"))
+		if err != nil {
+			limit = 100
+		}
+		if limit > 0 {
+			orderbook.Ask = truncateLevels(orderbook.Ask, limit)
+			orderbook.Bid = truncateLevels(orderbook.Bid, limit)
+		}
+		orderbook.Timestamp = now()
+		return orderbook, nil
+	case method == "GET" && match(parts, "public", "candles", "*"):
+		if _, ok := s.symbol(arg); !ok {
+			return nil, symbolNotFound()
+		}
+		return s.candlesPage(arg, form)
+	case method == "GET" && match(parts, "public", "trades", "*"):
+		if _, ok := s.symbol(arg); !ok {
+			return nil, symbolNotFound()
+		}
+		return s.publicTradesPage(arg, form)
+	case method == "GET" && match(parts, "trading", "balance"):
+		return s.sortedBalances(), nil
+	case method == "GET" && match(parts, "order"):
+		return filterOrders(s.orders, form.Get("symbol")), nil
+	case method == "GET" && match(parts, "order", "*"):
+		if i := s.activeOrder(arg); i >= 0 {
+			return s.orders[i], nil
+		}
+		return nil, orderNotFound()
+	case method == "POST" && match(parts, "order"):
+		return s.placeOrder(form.Get("clientOrderId"), form)
+	case method == "PUT" && match(parts, "order", "*"):
+		return s.placeOrder(arg, form)
+	case method == "DELETE" && match(parts, "order"):
+		return s.cancelOrders(form.Get("symbol")), nil
+	case method == "DELETE" && match(parts, "order", "*"):
+		i := s.activeOrder(arg)
+		if i < 0 {
+			return nil, orderNotFound()
+		}
+		return s.cancelOrder(i), nil
+	case method == "GET" && match(parts, "history", "order"):
+		orders := filterOrders(append(append([]hitbtc.Order(nil), s.orders...), s.history...), form.Get("symbol"))
+		if id := form.Get("clientOrderId"); id != "" {
+			orders = filterOrdersByID(orders, id)
+		}
+		orders, apiErr := betweenTimes(orders, form, func(o hitbtc.Order) time.Time { return o.Created })
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		// newest first, whatever the sort parameter, like the real server
+		sort.SliceStable(orders, func(i, j int) bool { return orders[i].Created.After(orders[j].Created) })
+		return paginate(len(orders), form, func(i int) interface{} { return orders[i] }), nil
+	case method == "GET" && match(parts, "history", "trades"):
+		var trades []hitbtc.Trade
+		for _, trade := range s.trades {
+			if symbol := form.Get("symbol"); symbol == "" || trade.Symbol == symbol {
+				trades = append(trades, trade)
+			}
+		}
+		trades, apiErr := betweenTimes(trades, form, func(t hitbtc.Trade) time.Time { return t.Timestamp })
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		sortHistory(trades, form)
+		return paginate(len(trades), form, func(i int) interface{} { return trades[i] }), nil
+	case method == "GET" && match(parts, "account", "transactions"):
+		transactions, apiErr := betweenTimes(s.transactions, form, func(t hitbtc.Transaction) time.Time { return t.Created })
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		sortHistory(transactions, form)
+		return paginate(len(transactions), form, func(i int) interface{} { return transactions[i] }), nil
+	case method == "POST" && match(parts, "account", "crypto", "withdraw"):
+		return map[string]string{"id": fmt.Sprintf("withdraw-%d", s.newID())}, nil
+	case method == "POST" && match(parts, "account", "transfer"):
+		return map[string]string{"id": fmt.Sprintf("transfer-%d", s.newID())}, nil
+	}
+	return nil, &hitbtc.APIError{HTTPStatus: http.StatusNotFound, Code: http.StatusNotFound, Message: "Not found"}
+}
+
+// symbol returns the symbol with the given id. s.mu must be held.
+func (s *Server) symbol(id string) (hitbtc.Symbol, bool) {
+	for _, symbol := range s.symbols {
+		if symbol.Id == id {
+			return symbol, true
+		}
+	}
+	return hitbtc.Symbol{}, false
+}
+
+// activeOrder returns the index of the active order with clientOrderId, or -1. s.mu must be held.
+func (s *Server) activeOrder(clientOrderId string) int {
+	for i, order := range s.orders {
+		if order.ClientOrderId == clientOrderId {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortedBalances returns the balances ordered by currency. s.mu must be held.
+func (s *Server) sortedBalances() []hitbtc.Balance {
+	balances := make([]hitbtc.Balance, 0, len(s.balances))
+	for _, balance := range s.balances {
+		balances = append(balances, balance)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Currency < balances[j].Currency })
+	return balances
+}
+
+// placeOrder validates and books a new order, reserving the funds it needs. s.mu must be held.
+func (s *Server) placeOrder(clientOrderId string, form url.Values) (hitbtc.Order, *hitbtc.APIError) {
+	symbol, ok := s.symbol(form.Get("symbol"))
+	if !ok {
+		return hitbtc.Order{}, symbolNotFound()
+	}
+	if clientOrderId == "" {
+		clientOrderId = fmt.Sprintf("fake-%d", s.newID())
+	} else if s.activeOrder(clientOrderId) >= 0 {
+		return hitbtc.Order{}, &hitbtc.APIError{HTTPStatus: http.StatusBadRequest, Code: hitbtc.ErrCodeDuplicateClientOrderId, Message: "Duplicate clientOrderId"}
+	}
+	side := form.Get("side")
+	if side != "buy" && side != "sell" {
+		return hitbtc.Order{}, validationError("side must be buy or sell")
+	}
+	quantity, err := decimal.NewFromString(form.Get("quantity"))
+	if err != nil || !quantity.IsPositive() {
+		return hitbtc.Order{}, validationError("invalid quantity")
+	}
+	orderType := form.Get("type")
+	if orderType == "" {
+		orderType = "limit"
+	}
+	price, _ := decimal.NewFromString(form.Get("price"))
+	if orderType == "limit" && !price.IsPositive() {
+		return hitbtc.Order{}, validationError("invalid price")
+	}
+
+	currency, amount := symbol.BaseCurrency, quantity
+	if side == "buy" {
+		currency, amount = symbol.QuoteCurrency, quantity.Mul(price)
+	}
+	balance := s.balances[currency]
+	if balance.Available.LessThan(amount) {
+		return hitbtc.Order{}, &hitbtc.APIError{HTTPStatus: http.StatusBadRequest, Code: hitbtc.ErrCodeInsufficientFunds, Message: "Insufficient funds", Description: "Check that the funds are sufficient, given commissions"}
+	}
+	balance.Available = balance.Available.Sub(amount)
+	balance.Reserved = balance.Reserved.Add(amount)
+	s.balances[currency] = balance
+
+	timeInForce := form.Get("timeInForce")
+	if timeInForce == "" {
+		timeInForce = "GTC"
+	}
+	timestamp := now()
+	order := hitbtc.Order{
+		ClientOrderId: clientOrderId,
+		Symbol:        symbol.Id,
+		Side:          side,
+		Status:        "new",
+		Type:          orderType,
+		TimeInForce:   timeInForce,
+		Quantity:      quantity,
+		Price:         price,
+		Created:       timestamp,
+		Updated:       timestamp,
+	}
+	s.orders = append(s.orders, order)
+	return order, nil
+}
+
+// cancelOrder cancels the active order at index i and releases its funds. s.mu must be held.
+func (s *Server) cancelOrder(i int) hitbtc.Order {
+	order := s.orders[i]
+	s.orders = append(s.orders[:i], s.orders[i+1:]...)
+
+	if symbol, ok := s.symbol(order.Symbol); ok {
+		remaining := order.RemainingQuantity()
+		currency, amount := symbol.BaseCurrency, remaining
+		if order.Side == "buy" {
+			currency, amount = symbol.QuoteCurrency, remaining.Mul(order.Price)
+		}
+		balance := s.balances[currency]
+		balance.Available = balance.Available.Add(amount)
+		balance.Reserved = balance.Reserved.Sub(amount)
+		s.balances[currency] = balance
+	}
+
+	order.Status = "canceled"
+	order.Updated = now()
+	s.history = append([]hitbtc.Order{order}, s.history...)
+	return order
+}
+
+// cancelOrders cancels every active order, of symbol only when not empty. s.mu must be held.
+func (s *Server) cancelOrders(symbol string) []hitbtc.Order {
+	canceled := []hitbtc.Order{}
+	for i := 0; i < len(s.orders); {
+		if symbol != "" && s.orders[i].Symbol != symbol {
+			i++
+			continue
+		}
+		canceled = append(canceled, s.cancelOrder(i))
+	}
+	return canceled
+}
+
+func match(parts []string, pattern ...string) bool {
+	if len(parts) != len(pattern) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func filterOrders(orders []hitbtc.Order, symbol string) []hitbtc.Order {
+	filtered := []hitbtc.Order{}
+	for _, order := range orders {
+		if symbol == "" || order.Symbol == symbol {
+			filtered = append(filtered, order)
+		}
+	}
+	return filtered
+}
+
+func filterOrdersByID(orders []hitbtc.Order, clientOrderId string) []hitbtc.Order {
+	filtered := []hitbtc.Order{}
+	for _, order := range orders {
+		if order.ClientOrderId == clientOrderId {
+			filtered = append(filtered, order)
+		}
+	}
+	return filtered
+}
+
+// truncateLevels returns the first limit levels of a side of a book.
+func truncateLevels(levels []hitbtc.OrderBookItem, limit int) []hitbtc.OrderBookItem {
+	if len(levels) > limit {
+		return levels[:limit]
+	}
+	return levels
+}
+
+// paginate applies the limit and offset parameters of form to a list of n items.
+func paginate(n int, form url.Values, item func(i int) interface{}) []interface{} {
+	offset, _ := strconv.Atoi(form.Get("offset"))
+	limit, err := strconv.Atoi(form.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	page := []interface{}{}
+	for i := offset; i < n && len(page) < limit; i++ {
+		page = append(page, item(i))
+	}
+	return page
+}
+
+// betweenTimes returns the items selected by the from and till parameters of
+// form, both inclusive, without changing their order.
+func betweenTimes[T any](items []T, form url.Values, timeOf func(T) time.Time) ([]T, *hitbtc.APIError) {
+	from, err := parseTime(form.Get("from"))
+	if err != nil {
+		return nil, validationError("invalid from")
+	}
+	till, err := parseTime(form.Get("till"))
+	if err != nil {
+		return nil, validationError("invalid till")
+	}
+	kept := []T{}
+	for _, item := range items {
+		t := timeOf(item)
+		if (from.IsZero() || !t.Before(from)) && (till.IsZero() || !t.After(till)) {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
+
+// sortHistory puts items, stored oldest first, in the order of the sort
+// parameter of form: newest first unless it is ASC.
+func sortHistory[T any](items []T, form url.Values) {
+	if strings.ToUpper(form.Get("sort")) == "ASC" {
+		return
+	}
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+}
+
+// candlesPage returns the candles of symbol selected by the period, sort, from,
+// till, limit and offset parameters of form. s.mu must be held.
+func (s *Server) candlesPage(symbol string, form url.Values) (interface{}, *hitbtc.APIError) {
+	period := hitbtc.Period(form.Get("period"))
+	if period == "" {
+		period = hitbtc.PeriodM30
+	}
+	from, err := parseTime(form.Get("from"))
+	if err != nil {
+		return nil, validationError("invalid from")
+	}
+	till, err := parseTime(form.Get("till"))
+	if err != nil {
+		return nil, validationError("invalid till")
+	}
+	if limit, _ := strconv.Atoi(form.Get("limit")); limit > 1000 {
+		return nil, validationError("limit must not exceed 1000")
+	}
+
+	var candles []hitbtc.Candle
+	for _, candle := range s.candles[candleKey(symbol, period)] {
+		if (from.IsZero() || !candle.Timestamp.Before(from)) && (till.IsZero() || !candle.Timestamp.After(till)) {
+			candles = append(candles, candle)
+		}
+	}
+	if strings.ToUpper(form.Get("sort")) == "DESC" {
+		for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+			candles[i], candles[j] = candles[j], candles[i]
+		}
+	}
+	return paginate(len(candles), form, func(i int) interface{} { return candles[i] }), nil
+}
+
+// publicTradesPage returns the market trades of symbol selected by the sort, by,
+// from, till, limit and offset parameters of form. s.mu must be held.
+func (s *Server) publicTradesPage(symbol string, form url.Values) (interface{}, *hitbtc.APIError) {
+	keep := func(hitbtc.PublicTrade) bool { return true }
+	switch hitbtc.TradesBy(form.Get("by")) {
+	case hitbtc.TradesByID:
+		from, _ := strconv.ParseUint(form.Get("from"), 10, 64)
+		till, _ := strconv.ParseUint(form.Get("till"), 10, 64)
+		keep = func(trade hitbtc.PublicTrade) bool {
+			return trade.Id >= from && (till == 0 || trade.Id <= till)
+		}
+	case "", hitbtc.TradesByTimestamp:
+		from, err := parseTime(form.Get("from"))
+		if err != nil {
+			return nil, validationError("invalid from")
+		}
+		till, err := parseTime(form.Get("till"))
+		if err != nil {
+			return nil, validationError("invalid till")
+		}
+		keep = func(trade hitbtc.PublicTrade) bool {
+			return (from.IsZero() || !trade.Timestamp.Before(from)) && (till.IsZero() || !trade.Timestamp.After(till))
+		}
+	default:
+		return nil, validationError("by must be timestamp or id")
+	}
+
+	var trades []hitbtc.PublicTrade
+	for _, trade := range s.publicTrades[symbol] {
+		if keep(trade) {
+			trades = append(trades, trade)
+		}
+	}
+	if strings.ToUpper(form.Get("sort")) != "ASC" {
+		for i, j := 0, len(trades)-1; i < j; i, j = i+1, j-1 {
+			trades[i], trades[j] = trades[j], trades[i]
+		}
+	}
+	return paginate(len(trades), form, func(i int) interface{} { return trades[i] }), nil
+}
+
+func candleKey(symbol string, period hitbtc.Period) string {
+	return symbol + ":" + string(period)
+}
+
+// parseTime parses a from or till parameter, given as a timestamp in milliseconds or an ISO 8601 date.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)).UTC(), nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// parseForm returns the query parameters and the url-encoded body of r, whatever its method.
+func parseForm(r *http.Request) (url.Values, error) {
+	form := r.URL.Query()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		form[key] = value
+	}
+	return form, nil
+}
+
+func writeError(w http.ResponseWriter, err *hitbtc.APIError) {
+	if err.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(err.RetryAfter/time.Second)))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.HTTPStatus)
+	json.NewEncoder(w).Encode(map[string]*hitbtc.APIError{"error": err})
+}
+
+func symbolNotFound() *hitbtc.APIError {
+	return &hitbtc.APIError{HTTPStatus: http.StatusBadRequest, Code: hitbtc.ErrCodeSymbolNotFound, Message: "Symbol not found"}
+}
+
+func currencyNotFound() *hitbtc.APIError {
+	return &hitbtc.APIError{HTTPStatus: http.StatusBadRequest, Code: hitbtc.ErrCodeCurrencyNotFound, Message: "Currency not found"}
+}
+
+func orderNotFound() *hitbtc.APIError {
+	return &hitbtc.APIError{HTTPStatus: http.StatusBadRequest, Code: hitbtc.ErrCodeOrderNotFound, Message: "Order not found"}
+}
+
+func validationError(description string) *hitbtc.APIError {
+	return &hitbtc.APIError{HTTPStatus: http.StatusBadRequest, Code: hitbtc.ErrCodeValidation, Message: "Validation error", Description: description}
+}
+
+// now returns the current time as the server reports it.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// d parses a decimal literal of the default market.
+func d(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
@@ -0,0 +1,626 @@
+package hitbtctest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	jsonrpc2 "github.com/sourcegraph/jsonrpc2"
+	jsonrpc2ws "github.com/sourcegraph/jsonrpc2/websocket"
+)
+
+// wsState tracks the websocket sessions of a Server. It is guarded by Server.mu.
+type wsState struct {
+	sessions  map[*jsonrpc2.Conn]*wsSession
+	sequences map[string]int64
+}
+
+// wsSession is a websocket connection and its subscriptions.
+type wsSession struct {
+	subscriptions map[string]bool
+	authenticated bool
+}
+
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	wsConn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	session := &wsSession{subscriptions: make(map[string]bool)}
+	conn := jsonrpc2.NewConn(context.Background(), objectStream{jsonrpc2ws.NewObjectStream(wsConn)}, wsHandler{s, session})
+
+	s.mu.Lock()
+	if s.ws.sessions == nil {
+		s.ws.sessions = make(map[*jsonrpc2.Conn]*wsSession)
+	}
+	s.ws.sessions[conn] = session
+	s.mu.Unlock()
+
+	<-conn.DisconnectNotify()
+
+	s.mu.Lock()
+	delete(s.ws.sessions, conn)
+	s.mu.Unlock()
+}
+
+// objectStream writes the messages of the server. Like the real server, it
+// sends the details of an error in a description field rather than as data.
+type objectStream struct {
+	jsonrpc2.ObjectStream
+}
+
+func (s objectStream) WriteObject(obj interface{}) error {
+	msg, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &fields); err != nil || fields["error"] == nil {
+		return s.ObjectStream.WriteObject(json.RawMessage(msg))
+	}
+	var errFields map[string]json.RawMessage
+	if err := json.Unmarshal(fields["error"], &errFields); err != nil {
+		return err
+	}
+	if data := errFields["data"]; data != nil {
+		delete(errFields, "data")
+		errFields["description"] = data
+	}
+	if fields["error"], err = json.Marshal(errFields); err != nil {
+		return err
+	}
+	return s.ObjectStream.WriteObject(fields)
+}
+
+// DropWSConnections closes every websocket connection, as a network failure
+// would. The server keeps accepting new connections.
+func (s *Server) DropWSConnections() {
+	s.closeWS()
+}
+
+// closeWS closes every websocket connection.
+func (s *Server) closeWS() {
+	s.mu.Lock()
+	conns := make([]*jsonrpc2.Conn, 0, len(s.ws.sessions))
+	for conn := range s.ws.sessions {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// WSSessions returns the number of open websocket connections.
+func (s *Server) WSSessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.ws.sessions)
+}
+
+// WSAuthenticatedSessions returns the number of open websocket connections that logged in.
+func (s *Server) WSAuthenticatedSessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, session := range s.ws.sessions {
+		if session.authenticated {
+			n++
+		}
+	}
+	return n
+}
+
+// Notify sends a notification to every websocket connection, subscribed or not.
+func (s *Server) Notify(method string, params interface{}) {
+	for _, conn := range s.subscribers("") {
+		conn.Notify(context.Background(), method, params)
+	}
+}
+
+// PublishTicker updates the ticker of ticker.Symbol and sends it to its subscribers.
+func (s *Server) PublishTicker(ticker hitbtc.Ticker) {
+	s.SetTicker(ticker)
+	for _, conn := range s.subscribers("ticker:" + ticker.Symbol) {
+		conn.Notify(context.Background(), "ticker", ticker)
+	}
+}
+
+// PublishTrade appends trade to the market trades of symbol and sends it to its subscribers.
+func (s *Server) PublishTrade(symbol string, trade hitbtc.PublicTrade) {
+	s.AddPublicTrades(symbol, trade)
+	update := map[string]interface{}{"data": trade, "symbol": symbol}
+	for _, conn := range s.subscribers("trades:" + symbol) {
+		conn.Notify(context.Background(), "updateTrades", update)
+	}
+}
+
+// PublishCandle sets the candle of symbol for period starting at candle.Timestamp
+// and sends it to the subscribers of that period.
+func (s *Server) PublishCandle(symbol string, period hitbtc.Period, candle hitbtc.Candle) {
+	s.mu.Lock()
+	key := candleKey(symbol, period)
+	candles := s.candles[key]
+	i := sort.Search(len(candles), func(i int) bool { return !candles[i].Timestamp.Before(candle.Timestamp) })
+	if i < len(candles) && candles[i].Timestamp.Equal(candle.Timestamp) {
+		candles[i] = candle
+	} else {
+		candles = append(candles, hitbtc.Candle{})
+		copy(candles[i+1:], candles[i:])
+		candles[i] = candle
+	}
+	s.candles[key] = candles
+	s.mu.Unlock()
+
+	update := map[string]interface{}{"data": candle, "symbol": symbol, "period": period}
+	for _, conn := range s.subscribers("candles:" + key) {
+		conn.Notify(context.Background(), "updateCandles", update)
+	}
+}
+
+// PublishOrderbookUpdate applies an order book update (a zero size removes a level)
+// and sends it to the subscribers of symbol.
+func (s *Server) PublishOrderbookUpdate(symbol string, ask, bid []hitbtc.OrderBookItem) {
+	update := s.updateOrderbook(symbol, ask, bid)
+	for _, conn := range s.subscribers("orderbook:" + symbol) {
+		conn.Notify(context.Background(), "updateOrderbook", update)
+	}
+}
+
+// DropOrderbookUpdate applies an order book update like PublishOrderbookUpdate
+// but does not send it, leaving a gap in the sequence seen by the subscribers.
+func (s *Server) DropOrderbookUpdate(symbol string, ask, bid []hitbtc.OrderBookItem) {
+	s.updateOrderbook(symbol, ask, bid)
+}
+
+// updateOrderbook applies an order book update and returns its notification.
+func (s *Server) updateOrderbook(symbol string, ask, bid []hitbtc.OrderBookItem) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orderbook := s.orderbooks[symbol]
+	orderbook.Ask = applyLevels(orderbook.Ask, ask, false)
+	orderbook.Bid = applyLevels(orderbook.Bid, bid, true)
+	s.orderbooks[symbol] = orderbook
+	if s.ws.sequences == nil {
+		s.ws.sequences = make(map[string]int64)
+	}
+	s.ws.sequences[symbol]++
+	return orderbookMessage(symbol, ask, bid, s.ws.sequences[symbol])
+}
+
+// subscribers returns the connections subscribed to key, or all of them when key is empty.
+func (s *Server) subscribers(key string) []*jsonrpc2.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var conns []*jsonrpc2.Conn
+	for conn, session := range s.ws.sessions {
+		if key == "" || session.subscriptions[key] {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// orderbookMessage builds a snapshot or update notification.
+func orderbookMessage(symbol string, ask, bid []hitbtc.OrderBookItem, sequence int64) interface{} {
+	if ask == nil {
+		ask = []hitbtc.OrderBookItem{}
+	}
+	if bid == nil {
+		bid = []hitbtc.OrderBookItem{}
+	}
+	return struct {
+		Ask      []hitbtc.OrderBookItem `json:"ask"`
+		Bid      []hitbtc.OrderBookItem `json:"bid"`
+		Symbol   string                 `json:"symbol"`
+		Sequence int64                  `json:"sequence"`
+	}{ask, bid, symbol, sequence}
+}
+
+// applyLevels merges updated price levels into a sorted side of a book.
+func applyLevels(levels, updates []hitbtc.OrderBookItem, descending bool) []hitbtc.OrderBookItem {
+	merged := append([]hitbtc.OrderBookItem(nil), levels...)
+	for _, update := range updates {
+		found := false
+		for i := range merged {
+			if merged[i].Price.Equal(update.Price) {
+				merged[i].Size = update.Size
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, update)
+		}
+	}
+	kept := merged[:0]
+	for _, level := range merged {
+		if !level.Size.IsZero() {
+			kept = append(kept, level)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool {
+		if descending {
+			return kept[i].Price.GreaterThan(kept[j].Price)
+		}
+		return kept[i].Price.LessThan(kept[j].Price)
+	})
+	return kept
+}
+
+// wsParams are the parameters of the websocket methods handled by the fake.
+type wsParams struct {
+	Currency string `json:"currency"`
+	Symbol   string `json:"symbol"`
+	Period   string `json:"period"`
+
+	ClientOrderId   string `json:"clientOrderId"`
+	RequestClientId string `json:"requestClientId"`
+	Side            string `json:"side"`
+	Type            string `json:"type"`
+	TimeInForce     string `json:"timeInForce"`
+	Quantity        string `json:"quantity"`
+	Price           string `json:"price"`
+	StopPrice       string `json:"stopPrice"`
+
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+	Sort   string          `json:"sort"`
+	By     string          `json:"by"`
+	From   json.RawMessage `json:"from"` // time or trade id
+	Till   json.RawMessage `json:"till"`
+
+	Algo      string `json:"algo"`
+	PKey      string `json:"pKey"`
+	SKey      string `json:"sKey"`
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+// wsHandler serves the requests of a websocket session.
+type wsHandler struct {
+	s       *Server
+	session *wsSession
+}
+
+func (h wsHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	h.s.handleWS(ctx, conn, h.session, req)
+}
+
+// handleWS serves a JSON-RPC request. Replies are written before any
+// notification triggered by the request, as the real server does.
+func (s *Server) handleWS(ctx context.Context, conn *jsonrpc2.Conn, session *wsSession, req *jsonrpc2.Request) {
+	if req.Notif {
+		return
+	}
+	var params wsParams
+	if req.Params != nil {
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			conn.ReplyWithError(ctx, req.ID, &jsonrpc2.Error{Code: hitbtc.ErrCodeValidation, Message: "Validation error", Data: req.Params})
+			return
+		}
+	}
+
+	s.mu.Lock()
+	result, followUp, apiErr := s.routeWS(session, req.Method, params)
+	s.mu.Unlock()
+
+	if apiErr != nil {
+		rpcErr := &jsonrpc2.Error{Code: int64(apiErr.Code), Message: apiErr.Message}
+		if apiErr.Description != "" {
+			rpcErr.SetError(apiErr.Description)
+		}
+		conn.ReplyWithError(ctx, req.ID, rpcErr)
+		return
+	}
+	conn.Reply(ctx, req.ID, result)
+	if followUp != nil {
+		conn.Notify(ctx, followUp.method, followUp.params)
+	}
+	switch req.Method {
+	case "newOrder", "cancelOrder", "cancelReplaceOrder":
+		s.publishReport(result)
+	}
+}
+
+// publishReport sends an execution report to the sessions subscribed to reports.
+func (s *Server) publishReport(report interface{}) {
+	for _, conn := range s.subscribers("reports") {
+		conn.Notify(context.Background(), "report", report)
+	}
+}
+
+// FillOrder executes quantity of the active order clientOrderId at its price,
+// settles the balances and sends a trade report. A fully executed order is
+// moved to the order history. It returns false if the order is not active.
+func (s *Server) FillOrder(clientOrderId string, quantity decimal.Decimal) (hitbtc.Order, bool) {
+	s.mu.Lock()
+	i := s.activeOrder(clientOrderId)
+	if i < 0 {
+		s.mu.Unlock()
+		return hitbtc.Order{}, false
+	}
+	order := s.orders[i]
+	if remaining := order.RemainingQuantity(); quantity.GreaterThan(remaining) {
+		quantity = remaining
+	}
+	order.CumQuantity = order.CumQuantity.Add(quantity)
+	order.Updated = now()
+	order.Status = "partiallyFilled"
+	if order.RemainingQuantity().IsZero() {
+		order.Status = "filled"
+		s.orders = append(s.orders[:i], s.orders[i+1:]...)
+		s.history = append([]hitbtc.Order{order}, s.history...)
+	} else {
+		s.orders[i] = order
+	}
+
+	if symbol, ok := s.symbol(order.Symbol); ok {
+		spent, received := symbol.BaseCurrency, symbol.QuoteCurrency
+		spentAmount, receivedAmount := quantity, quantity.Mul(order.Price)
+		if order.Side == "buy" {
+			spent, received = received, spent
+			spentAmount, receivedAmount = receivedAmount, spentAmount
+		}
+		balance := s.balances[spent]
+		balance.Reserved = balance.Reserved.Sub(spentAmount)
+		s.balances[spent] = balance
+		balance = s.balances[received]
+		balance.Currency = received
+		balance.Available = balance.Available.Add(receivedAmount)
+		s.balances[received] = balance
+	}
+
+	tradeID := s.newID()
+	s.trades = append(s.trades, hitbtc.Trade{Id: tradeID, ClientOrderId: order.ClientOrderId, Symbol: order.Symbol, Type: order.Side, Price: order.Price, Quantity: quantity, Fee: decimal.Zero, Timestamp: order.Updated})
+	s.mu.Unlock()
+
+	s.publishReport(struct {
+		hitbtc.Order
+		ReportType    string          `json:"reportType"`
+		TradeId       uint64          `json:"tradeId"`
+		TradeQuantity decimal.Decimal `json:"tradeQuantity"`
+		TradePrice    decimal.Decimal `json:"tradePrice"`
+		TradeFee      decimal.Decimal `json:"tradeFee"`
+	}{order, "trade", tradeID, quantity, order.Price, decimal.Zero})
+	return order, true
+}
+
+// wsNotification is a notification sent right after a reply.
+type wsNotification struct {
+	method string
+	params interface{}
+}
+
+// routeWS dispatches a websocket request. s.mu must be held.
+func (s *Server) routeWS(session *wsSession, method string, params wsParams) (interface{}, *wsNotification, *hitbtc.APIError) {
+	switch method {
+	case "login":
+		if apiErr := s.login(params); apiErr != nil {
+			return nil, nil, apiErr
+		}
+		session.authenticated = true
+		return true, nil, nil
+	case "subscribeReports":
+		if !session.authenticated {
+			return nil, nil, &hitbtc.APIError{Code: hitbtc.ErrCodeAuthRequired, Message: "Authorization required"}
+		}
+		session.subscriptions["reports"] = true
+		reports := make([]interface{}, 0, len(s.orders))
+		for _, order := range s.orders {
+			reports = append(reports, report(order, "status"))
+		}
+		return true, &wsNotification{"activeOrders", reports}, nil
+	case "newOrder", "cancelOrder", "cancelReplaceOrder", "getOrders", "getTradingBalance":
+		if !session.authenticated {
+			return nil, nil, &hitbtc.APIError{Code: hitbtc.ErrCodeAuthRequired, Message: "Authorization required"}
+		}
+		result, apiErr := s.routeWSTrading(method, params)
+		return result, nil, apiErr
+	case "getCurrency":
+		for _, currency := range s.currencies {
+			if currency.Id == params.Currency {
+				return currency, nil, nil
+			}
+		}
+		return nil, nil, currencyNotFound()
+	case "getSymbol":
+		if symbol, ok := s.symbol(params.Symbol); ok {
+			return symbol, nil, nil
+		}
+		return nil, nil, symbolNotFound()
+	case "getCurrencies":
+		return append([]hitbtc.Currency{}, s.currencies...), nil, nil
+	case "getSymbols":
+		return append([]hitbtc.Symbol{}, s.symbols...), nil, nil
+	case "getTrades":
+		if _, ok := s.symbol(params.Symbol); !ok {
+			return nil, nil, symbolNotFound()
+		}
+		page, apiErr := s.publicTradesPage(params.Symbol, params.tradesForm())
+		if apiErr != nil {
+			return nil, nil, apiErr
+		}
+		return map[string]interface{}{"data": page, "symbol": params.Symbol}, nil, nil
+	case "subscribeTicker", "subscribeOrderbook", "subscribeTrades", "subscribeCandles":
+		if _, ok := s.symbol(params.Symbol); !ok {
+			return nil, nil, symbolNotFound()
+		}
+		session.subscriptions[subscriptionKey(method, params)] = true
+		return true, s.snapshot(method, params), nil
+	case "unsubscribeTicker", "unsubscribeOrderbook", "unsubscribeTrades", "unsubscribeCandles":
+		delete(session.subscriptions, subscriptionKey(method, params))
+		return true, nil, nil
+	}
+	return nil, nil, &hitbtc.APIError{Code: -32601, Message: "Method not found"}
+}
+
+// routeWSTrading dispatches the trading methods of an authenticated session. s.mu must be held.
+func (s *Server) routeWSTrading(method string, params wsParams) (interface{}, *hitbtc.APIError) {
+	switch method {
+	case "newOrder":
+		order, apiErr := s.placeOrder(params.ClientOrderId, params.orderForm())
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		return report(order, "new"), nil
+	case "cancelOrder":
+		i := s.activeOrder(params.ClientOrderId)
+		if i < 0 {
+			return nil, orderNotFound()
+		}
+		return report(s.cancelOrder(i), "canceled"), nil
+	case "cancelReplaceOrder":
+		i := s.activeOrder(params.ClientOrderId)
+		if i < 0 {
+			return nil, orderNotFound()
+		}
+		replaced := s.orders[i]
+		s.cancelOrder(i)
+		order, apiErr := s.placeOrder(params.RequestClientId, url.Values{
+			"symbol":      {replaced.Symbol},
+			"side":        {replaced.Side},
+			"type":        {replaced.Type},
+			"timeInForce": {replaced.TimeInForce},
+			"quantity":    {params.Quantity},
+			"price":       {params.Price},
+		})
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		return struct {
+			hitbtc.Order
+			ReportType                   string `json:"reportType"`
+			OriginalRequestClientOrderId string `json:"originalRequestClientOrderId"`
+		}{order, "replaced", replaced.ClientOrderId}, nil
+	case "getOrders":
+		return append([]hitbtc.Order{}, s.orders...), nil
+	default:
+		return s.sortedBalances(), nil
+	}
+}
+
+// orderForm returns the parameters of a newOrder request in the form used by the REST API.
+func (p wsParams) orderForm() url.Values {
+	form := url.Values{}
+	for key, value := range map[string]string{
+		"symbol":      p.Symbol,
+		"side":        p.Side,
+		"type":        p.Type,
+		"timeInForce": p.TimeInForce,
+		"quantity":    p.Quantity,
+		"price":       p.Price,
+		"stopPrice":   p.StopPrice,
+	} {
+		if value != "" {
+			form.Set(key, value)
+		}
+	}
+	return form
+}
+
+// tradesForm returns the filters of a getTrades request in the form used by the REST API.
+func (p wsParams) tradesForm() url.Values {
+	form := url.Values{}
+	for key, value := range map[string]string{
+		"sort": p.Sort,
+		"by":   p.By,
+		"from": rawParam(p.From),
+		"till": rawParam(p.Till),
+	} {
+		if value != "" {
+			form.Set(key, value)
+		}
+	}
+	if p.Limit > 0 {
+		form.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Offset > 0 {
+		form.Set("offset", strconv.Itoa(p.Offset))
+	}
+	return form
+}
+
+// rawParam returns a string or number parameter as text.
+func rawParam(raw json.RawMessage) string {
+	var value string
+	if json.Unmarshal(raw, &value) == nil {
+		return value
+	}
+	return string(raw)
+}
+
+// report returns an order as sent by the trading methods, with the type of the change.
+func report(order hitbtc.Order, reportType string) interface{} {
+	return struct {
+		hitbtc.Order
+		ReportType string `json:"reportType"`
+	}{order, reportType}
+}
+
+// login checks the credentials of a login request.
+func (s *Server) login(params wsParams) *hitbtc.APIError {
+	if params.PKey != s.APIKey {
+		return &hitbtc.APIError{Code: hitbtc.ErrCodeAuthFailed, Message: "Authorization failed"}
+	}
+	switch params.Algo {
+	case string(hitbtc.LoginBasic):
+		if params.SKey == s.APISecret {
+			return nil
+		}
+	case string(hitbtc.LoginHS256):
+		mac := hmac.New(sha256.New, []byte(s.APISecret))
+		mac.Write([]byte(params.Nonce))
+		if params.Nonce != "" && hmac.Equal([]byte(params.Signature), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+			return nil
+		}
+	default:
+		return &hitbtc.APIError{Code: hitbtc.ErrCodeUnsupportedAuth, Message: "Unsupported authorisation method"}
+	}
+	return &hitbtc.APIError{Code: hitbtc.ErrCodeAuthFailed, Message: "Authorization failed"}
+}
+
+// snapshot returns the snapshot notification sent after a subscription, if any. s.mu must be held.
+func (s *Server) snapshot(method string, params wsParams) *wsNotification {
+	switch method {
+	case "subscribeOrderbook":
+		orderbook := s.orderbooks[params.Symbol]
+		return &wsNotification{"snapshotOrderbook", orderbookMessage(params.Symbol, orderbook.Ask, orderbook.Bid, s.ws.sequences[params.Symbol])}
+	case "subscribeTrades":
+		trades := append([]hitbtc.PublicTrade{}, s.publicTrades[params.Symbol]...)
+		return &wsNotification{"snapshotTrades", map[string]interface{}{"data": trades, "symbol": params.Symbol}}
+	case "subscribeCandles":
+		candles := s.candles[candleKey(params.Symbol, hitbtc.Period(params.Period))]
+		if candles == nil {
+			candles = []hitbtc.Candle{}
+		}
+		return &wsNotification{"snapshotCandles", map[string]interface{}{"data": candles, "symbol": params.Symbol, "period": params.Period}}
+	}
+	return nil
+}
+
+// subscriptionKey returns the key of the feed a (un)subscribe method refers to.
+func subscriptionKey(method string, params wsParams) string {
+	switch method {
+	case "subscribeTicker", "unsubscribeTicker":
+		return "ticker:" + params.Symbol
+	case "subscribeOrderbook", "unsubscribeOrderbook":
+		return "orderbook:" + params.Symbol
+	case "subscribeTrades", "unsubscribeTrades":
+		return "trades:" + params.Symbol
+	default:
+		return "candles:" + params.Symbol + ":" + params.Period
+	}
+}
@@ -0,0 +1,200 @@
+package hitbtc
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// defaultPageSize is the page size of the iterators when none is set.
+const defaultPageSize = 100
+
+// HistoryQuery selects the items walked by a history iterator.
+// Zero fields are not sent and the API defaults apply.
+type HistoryQuery struct {
+	Symbol   string    // ignored by the transaction history
+	Sort     SortOrder // newest first by default; the order history is always newest first and only accepts SortDesc
+	From     time.Time
+	Till     time.Time
+	PageSize int // items per request, 100 by default and at most 1000
+}
+
+// pager walks the consecutive pages of a history endpoint. It moves by the
+// time of the last item seen, passed as till when newest first or as from when
+// oldest first, so that the items added while paging are neither repeated nor
+// skipped. The offset only skips the items already seen at that time, which
+// makes it the whole cursor when the items have no time. This relies on from
+// and till being inclusive, as they are on HitBTC: an exclusive bound would
+// make the offset skip unseen items.
+type pager[T any] struct {
+	client    *HitBtc
+	resource  string
+	payload   map[string]string
+	pageSize  int
+	ascending bool
+	timeOf    func(T) time.Time
+
+	page    []T
+	current T
+	last    time.Time // time of the last item seen
+	seen    int       // items seen at last
+	done    bool
+	err     error
+}
+
+// newPager returns the pager of resource. Unless sorted, the endpoint has no
+// sort parameter and returns the newest items first. An unsupported sort order
+// is reported by Err.
+func newPager[T any](client *HitBtc, resource string, query HistoryQuery, sorted bool, timeOf func(T) time.Time) pager[T] {
+	payload := make(map[string]string)
+	if query.Symbol != "" {
+		payload["symbol"] = query.Symbol
+	}
+	sortOrder := SortOrder(strings.ToUpper(string(query.Sort)))
+	var err error
+	switch {
+	case sortOrder != "" && sortOrder != SortAsc && sortOrder != SortDesc:
+		err = errors.Errorf("Hitbtc %s: unsupported sort order %q", resource, query.Sort)
+	case !sorted && sortOrder == SortAsc:
+		err = errors.Errorf("Hitbtc %s: the history is only sorted newest first", resource)
+	case sorted && sortOrder != "":
+		payload["sort"] = string(sortOrder)
+	}
+	if !query.From.IsZero() {
+		payload["from"] = formatTime(query.From)
+	}
+	if !query.Till.IsZero() {
+		payload["till"] = formatTime(query.Till)
+	}
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	payload["limit"] = strconv.Itoa(pageSize)
+	return pager[T]{
+		client:    client,
+		resource:  resource,
+		payload:   payload,
+		pageSize:  pageSize,
+		ascending: sortOrder == SortAsc,
+		timeOf:    timeOf,
+		err:       err,
+	}
+}
+
+// Next advances to the next item, fetching a new page when needed.
+// It returns false when the history is exhausted or on error; see Err.
+func (p *pager[T]) Next(ctx context.Context) bool {
+	for len(p.page) == 0 {
+		if !p.nextPage(ctx) {
+			return false
+		}
+	}
+	p.current, p.page = p.page[0], p.page[1:]
+	return true
+}
+
+// nextPage fetches the page following the last item seen and reports whether
+// it has items. It returns false at the end of the history or on error.
+func (p *pager[T]) nextPage(ctx context.Context) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+	if !p.last.IsZero() {
+		if p.ascending {
+			p.payload["from"] = formatTime(p.last)
+		} else {
+			p.payload["till"] = formatTime(p.last)
+		}
+	}
+	p.payload["offset"] = strconv.Itoa(p.seen)
+	r, err := p.client.client.do(ctx, "GET", p.resource, p.payload, true)
+	if err != nil {
+		p.err = err
+		return false
+	}
+	var response interface{}
+	if err = json.Unmarshal(r, &response); err != nil {
+		p.err = err
+		return false
+	}
+	if err = handleErr(response); err != nil {
+		p.err = err
+		return false
+	}
+	var page []T
+	if err = json.Unmarshal(r, &page); err != nil {
+		p.err = err
+		return false
+	}
+	for _, item := range page {
+		if t := p.timeOf(item); t.Equal(p.last) {
+			p.seen++
+		} else {
+			p.last, p.seen = t, 1
+		}
+	}
+	if len(page) < p.pageSize {
+		p.done = true
+	}
+	p.page = page
+	return len(page) > 0
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (p *pager[T]) Err() error {
+	return p.err
+}
+
+// TradeIterator walks the trade history of the user.
+type TradeIterator struct {
+	pager[Trade]
+}
+
+// IterateTrades returns an iterator over the trades selected by query.
+func (b *HitBtc) IterateTrades(query HistoryQuery) *TradeIterator {
+	return &TradeIterator{newPager(b, "history/trades", query, true, func(t Trade) time.Time { return t.Timestamp })}
+}
+
+// Trade returns the current trade.
+func (it *TradeIterator) Trade() Trade {
+	return it.current
+}
+
+// OrderIterator walks the order history of the user.
+type OrderIterator struct {
+	pager[Order]
+}
+
+// IterateOrderHistory returns an iterator over the closed orders selected by query.
+func (b *HitBtc) IterateOrderHistory(query HistoryQuery) *OrderIterator {
+	return &OrderIterator{newPager(b, "history/order", query, false, func(o Order) time.Time { return o.Created })}
+}
+
+// Order returns the current order.
+func (it *OrderIterator) Order() Order {
+	return it.current
+}
+
+// TransactionIterator walks the account transactions of the user.
+type TransactionIterator struct {
+	pager[Transaction]
+}
+
+// IterateTransactions returns an iterator over the transactions selected by query.
+func (b *HitBtc) IterateTransactions(query HistoryQuery) *TransactionIterator {
+	query.Symbol = ""
+	return &TransactionIterator{newPager(b, "account/transactions", query, true, func(t Transaction) time.Time { return t.Created })}
+}
+
+// Transaction returns the current transaction.
+func (it *TransactionIterator) Transaction() Transaction {
+	return it.current
+}
@@ -0,0 +1,160 @@
+package hitbtc_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterateTrades(t *testing.T) {
+	defer server.Reset()
+
+	for i := 1; i <= 250; i++ {
+		server.AddTrades(hitbtc.Trade{Id: uint64(i), Symbol: "ETHBTC", Type: "buy"})
+	}
+
+	it := hitBtc.IterateTrades(hitbtc.HistoryQuery{Symbol: "ETHBTC", PageSize: 100})
+	var ids []uint64
+	for it.Next(context.Background()) {
+		ids = append(ids, it.Trade().Id)
+	}
+	require.NoError(t, it.Err(), defaultErrorMessage)
+	require.Len(t, ids, 250)
+	require.Equal(t, uint64(250), ids[0], "newest first")
+	require.Equal(t, uint64(1), ids[249])
+
+	it = hitBtc.IterateTrades(hitbtc.HistoryQuery{Symbol: "ETHBTC", Sort: hitbtc.SortAsc, PageSize: 100})
+	ids = nil
+	for it.Next(context.Background()) {
+		ids = append(ids, it.Trade().Id)
+	}
+	require.NoError(t, it.Err(), defaultErrorMessage)
+	require.Len(t, ids, 250)
+	require.Equal(t, uint64(1), ids[0])
+	require.Equal(t, uint64(250), ids[249])
+}
+
+func TestIterateLiveHistory(t *testing.T) {
+	defer server.Reset()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	trade := func(id int) hitbtc.Trade {
+		// two trades per second, so that pages end between trades of the same time
+		return hitbtc.Trade{Id: uint64(id), Symbol: "ETHBTC", Timestamp: start.Add(time.Duration(id/2) * time.Second)}
+	}
+	for i := 1; i <= 25; i++ {
+		server.AddTrades(trade(i))
+	}
+
+	next := 1000
+	for _, sort := range []hitbtc.SortOrder{hitbtc.SortDesc, hitbtc.SortAsc} {
+		it := hitBtc.IterateTrades(hitbtc.HistoryQuery{Symbol: "ETHBTC", Sort: sort, PageSize: 7})
+		var ids []uint64
+		for it.Next(context.Background()) {
+			ids = append(ids, it.Trade().Id)
+			if len(ids)%5 == 0 {
+				// trades made while paging
+				server.AddTrades(trade(next), trade(next+1))
+				next += 2
+			}
+		}
+		require.NoError(t, it.Err(), defaultErrorMessage)
+
+		seen := make(map[uint64]bool)
+		for _, id := range ids {
+			require.False(t, seen[id], "%s: trade %d repeated", sort, id)
+			seen[id] = true
+		}
+		for i := 1; i <= 25; i++ {
+			require.True(t, seen[uint64(i)], "%s: trade %d skipped", sort, i)
+		}
+	}
+}
+
+func TestIterateOrderHistoryAndTransactions(t *testing.T) {
+	defer server.Reset()
+
+	for i := 0; i < 30; i++ {
+		server.AddOrderHistory(hitbtc.Order{ClientOrderId: fmt.Sprint(i), Symbol: "ETHBTC", Status: "filled"})
+		server.AddTransactions(hitbtc.Transaction{Id: fmt.Sprint(i), Currency: "BTC"})
+	}
+
+	orders := hitBtc.IterateOrderHistory(hitbtc.HistoryQuery{PageSize: 7})
+	n := 0
+	for orders.Next(context.Background()) {
+		require.Equal(t, fmt.Sprint(n), orders.Order().ClientOrderId)
+		n++
+	}
+	require.NoError(t, orders.Err(), defaultErrorMessage)
+	require.Equal(t, 30, n)
+
+	transactions := hitBtc.IterateTransactions(hitbtc.HistoryQuery{PageSize: 10})
+	n = 0
+	for transactions.Next(context.Background()) {
+		n++
+	}
+	require.NoError(t, transactions.Err(), defaultErrorMessage)
+	require.Equal(t, 30, n, "a full last page is followed by an empty one")
+}
+
+func TestIterateOrderHistoryNewestFirst(t *testing.T) {
+	defer server.Reset()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		server.AddOrderHistory(hitbtc.Order{ClientOrderId: fmt.Sprint(i), Symbol: "ETHBTC", Status: "filled", Created: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	for _, sort := range []hitbtc.SortOrder{"", hitbtc.SortDesc} {
+		orders := hitBtc.IterateOrderHistory(hitbtc.HistoryQuery{Sort: sort, PageSize: 5})
+		var ids []string
+		for orders.Next(context.Background()) {
+			ids = append(ids, orders.Order().ClientOrderId)
+		}
+		require.NoError(t, orders.Err(), defaultErrorMessage)
+		require.Equal(t, []string{"11", "10", "9", "8", "7", "6", "5", "4", "3", "2", "1", "0"}, ids, "sort %q", sort)
+	}
+}
+
+func TestIteratorUnsupportedSort(t *testing.T) {
+	defer server.Reset()
+
+	for i := 1; i <= 3; i++ {
+		server.AddTrades(hitbtc.Trade{Id: uint64(i), Symbol: "ETHBTC"})
+		server.AddOrderHistory(hitbtc.Order{ClientOrderId: fmt.Sprint(i), Symbol: "ETHBTC", Status: "filled"})
+	}
+
+	orders := hitBtc.IterateOrderHistory(hitbtc.HistoryQuery{Sort: hitbtc.SortAsc})
+	require.False(t, orders.Next(context.Background()), "the order history is only sorted newest first")
+	require.Error(t, orders.Err())
+
+	trades := hitBtc.IterateTrades(hitbtc.HistoryQuery{Sort: "random"})
+	require.False(t, trades.Next(context.Background()))
+	require.Error(t, trades.Err())
+
+	trades = hitBtc.IterateTrades(hitbtc.HistoryQuery{Sort: "asc"})
+	var ids []uint64
+	for trades.Next(context.Background()) {
+		ids = append(ids, trades.Trade().Id)
+	}
+	require.NoError(t, trades.Err(), defaultErrorMessage)
+	require.Equal(t, []uint64{1, 2, 3}, ids, "the sort order is case insensitive")
+}
+
+func TestIteratorError(t *testing.T) {
+	defer server.Reset()
+
+	for i := 1; i <= 5; i++ {
+		server.AddTrades(hitbtc.Trade{Id: uint64(i), Symbol: "ETHBTC"})
+	}
+	server.FailNext("GET", "history/trades", hitbtc.APIError{Code: hitbtc.ErrCodeValidation, Message: "Validation error"})
+
+	it := hitBtc.IterateTrades(hitbtc.HistoryQuery{PageSize: 2})
+	require.False(t, it.Next(context.Background()))
+	require.Error(t, it.Err())
+	require.False(t, it.Next(context.Background()), "the iterator stays stopped")
+}
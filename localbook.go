@@ -0,0 +1,253 @@
+package hitbtc
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/shopspring/decimal"
+)
+
+// OrderBook is a local copy of the order book of a market, maintained from the
+// websocket snapshot and update notifications.
+//
+// Updates are applied in sequence; when one is missing, the book is marked
+// out of sync and a new snapshot is requested. All the methods are safe for
+// concurrent use.
+type OrderBook struct {
+	ws     *WSClient
+	symbol string
+
+	mu        sync.RWMutex
+	asks      []OrderBookItem // lowest price first
+	bids      []OrderBookItem // highest price first
+	sequence  int64
+	synced    bool
+	resyncing bool
+	handlers  []func(*OrderBook)
+}
+
+// NewOrderBook subscribes to the order book notifications of symbol and returns
+// the book they maintain. The book is empty until the snapshot is received; see Synced.
+// Only one OrderBook per symbol can be maintained by a client.
+func NewOrderBook(ws *WSClient, symbol string) (*OrderBook, error) {
+	book := &OrderBook{ws: ws, symbol: symbol}
+
+	ws.subMu.Lock()
+	defer ws.subMu.Unlock()
+
+	ws.updates.mu.Lock()
+	if ws.updates.books[symbol] != nil {
+		ws.updates.mu.Unlock()
+		return nil, errors.Errorf("Hitbtc NewOrderBook: %s already has an order book", symbol)
+	}
+	ws.updates.books[symbol] = book
+	ws.updates.mu.Unlock()
+
+	if err := ws.subscriptionOp(context.Background(), "subscribeOrderbook", symbol, true); err != nil {
+		ws.updates.mu.Lock()
+		delete(ws.updates.books, symbol)
+		ws.updates.mu.Unlock()
+		return nil, errors.Annotate(err, "Hitbtc NewOrderBook")
+	}
+	return book, nil
+}
+
+// Close stops maintaining the book. The order book notifications are
+// unsubscribed unless they are also read with SubscribeOrderbook or OnOrderbook.
+func (b *OrderBook) Close() error {
+	b.ws.subMu.Lock()
+	defer b.ws.subMu.Unlock()
+
+	updates := b.ws.updates
+	updates.mu.Lock()
+	if updates.books[b.symbol] != b {
+		updates.mu.Unlock()
+		return nil
+	}
+	delete(updates.books, b.symbol)
+	updates.mu.Unlock()
+
+	if err := b.ws.subscriptionOp(context.Background(), "unsubscribeOrderbook", b.symbol, false); err != nil {
+		return errors.Annotate(err, "Hitbtc OrderBook.Close")
+	}
+	return nil
+}
+
+// Symbol returns the market of the book.
+func (b *OrderBook) Symbol() string {
+	return b.symbol
+}
+
+// OnChange registers fn to be called after every snapshot or update applied to
+// the book. fn is called from the goroutine reading the notifications and must not block.
+func (b *OrderBook) OnChange(fn func(*OrderBook)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, fn)
+}
+
+// Synced reports whether the book holds a snapshot and all the updates that followed it.
+func (b *OrderBook) Synced() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.synced
+}
+
+// Sequence returns the sequence number of the last applied snapshot or update.
+func (b *OrderBook) Sequence() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.sequence
+}
+
+// BestBid returns the highest bid, if any.
+func (b *OrderBook) BestBid() (OrderBookItem, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.bids) == 0 {
+		return OrderBookItem{}, false
+	}
+	return b.bids[0], true
+}
+
+// BestAsk returns the lowest ask, if any.
+func (b *OrderBook) BestAsk() (OrderBookItem, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.asks) == 0 {
+		return OrderBookItem{}, false
+	}
+	return b.asks[0], true
+}
+
+// Mid returns the price halfway between the best bid and the best ask,
+// or false if a side of the book is empty.
+func (b *OrderBook) Mid() (decimal.Decimal, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.asks) == 0 || len(b.bids) == 0 {
+		return decimal.Zero, false
+	}
+	return b.asks[0].Price.Add(b.bids[0].Price).Div(decimal.NewFromInt(2)), true
+}
+
+// Depth returns a copy of the n best levels of each side, best first.
+// A n of 0 returns the whole book.
+func (b *OrderBook) Depth(n int) (asks, bids []OrderBookItem) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return topLevels(b.asks, n), topLevels(b.bids, n)
+}
+
+func topLevels(levels []OrderBookItem, n int) []OrderBookItem {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	return append([]OrderBookItem(nil), levels[:n]...)
+}
+
+// applySnapshot replaces the content of the book.
+func (b *OrderBook) applySnapshot(msg WSNotificationOrderbookSnapshot) {
+	b.mu.Lock()
+	b.asks = b.asks[:0]
+	b.bids = b.bids[:0]
+	for _, level := range msg.Ask {
+		b.asks = setLevel(b.asks, level, false)
+	}
+	for _, level := range msg.Bid {
+		b.bids = setLevel(b.bids, level, true)
+	}
+	b.sequence = msg.Sequence
+	b.synced = true
+	b.resyncing = false
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	b.notify(handlers)
+}
+
+// applyUpdate applies the changed levels of the book, or asks for a new
+// snapshot if updates were missed.
+func (b *OrderBook) applyUpdate(msg WSNotificationOrderbookUpdate) {
+	b.mu.Lock()
+	if !b.synced || msg.Sequence <= b.sequence {
+		// waiting for a snapshot, or an update older than the snapshot
+		b.mu.Unlock()
+		return
+	}
+	if msg.Sequence != b.sequence+1 {
+		b.synced = false
+		resync := !b.resyncing
+		b.resyncing = true
+		b.mu.Unlock()
+		if resync {
+			go b.resync()
+		}
+		return
+	}
+	for _, level := range msg.Ask {
+		b.asks = setLevel(b.asks, level, false)
+	}
+	for _, level := range msg.Bid {
+		b.bids = setLevel(b.bids, level, true)
+	}
+	b.sequence = msg.Sequence
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	b.notify(handlers)
+}
+
+// desync marks the book out of sync after a reconnection; the replayed
+// subscription brings a new snapshot.
+func (b *OrderBook) desync() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.synced = false
+	b.resyncing = true
+}
+
+// resync subscribes again to the order book to receive a new snapshot.
+func (b *OrderBook) resync() {
+	b.ws.subMu.Lock()
+	defer b.ws.subMu.Unlock()
+
+	if err := b.ws.subscriptionOp(context.Background(), "subscribeOrderbook", b.symbol, false); err != nil {
+		b.mu.Lock()
+		b.resyncing = false
+		b.mu.Unlock()
+		b.ws.updates.reportError(errors.Annotate(err, "Hitbtc OrderBook resync"))
+	}
+}
+
+func (b *OrderBook) notify(handlers []func(*OrderBook)) {
+	for _, handler := range handlers {
+		handler(b)
+	}
+}
+
+// setLevel sets a price level in a sorted side of a book; a zero size removes the level.
+func setLevel(levels []OrderBookItem, level OrderBookItem, descending bool) []OrderBookItem {
+	i := sort.Search(len(levels), func(i int) bool {
+		if descending {
+			return levels[i].Price.LessThanOrEqual(level.Price)
+		}
+		return levels[i].Price.GreaterThanOrEqual(level.Price)
+	})
+	found := i < len(levels) && levels[i].Price.Equal(level.Price)
+	switch {
+	case level.Size.IsZero() && found:
+		return append(levels[:i], levels[i+1:]...)
+	case level.Size.IsZero():
+		return levels
+	case found:
+		levels[i] = level
+		return levels
+	}
+	levels = append(levels, OrderBookItem{})
+	copy(levels[i+1:], levels[i:])
+	levels[i] = level
+	return levels
+}
@@ -0,0 +1,115 @@
+package hitbtc_test
+
+import (
+	"testing"
+	"time"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func level(price, size string) hitbtc.OrderBookItem {
+	return hitbtc.OrderBookItem{Price: decimal.RequireFromString(price), Size: decimal.RequireFromString(size)}
+}
+
+func TestOrderBook(t *testing.T) {
+	defer server.Reset()
+	client := newWSClient(t)
+	defer client.Close()
+
+	book, err := hitbtc.NewOrderBook(client, "ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	defer book.Close()
+	require.Eventually(t, book.Synced, 5*time.Second, 10*time.Millisecond)
+
+	bid, ok := book.BestBid()
+	require.True(t, ok)
+	require.Equal(t, "0.07", bid.Price.String())
+	ask, _ := book.BestAsk()
+	require.Equal(t, "0.0701", ask.Price.String())
+	mid, _ := book.Mid()
+	require.Equal(t, "0.07005", mid.String())
+
+	sequence := book.Sequence()
+	server.PublishOrderbookUpdate("ETHBTC", []hitbtc.OrderBookItem{level("0.0701", "0")}, []hitbtc.OrderBookItem{level("0.07005", "1")})
+	require.Eventually(t, func() bool { return book.Sequence() == sequence+1 }, 5*time.Second, 10*time.Millisecond)
+
+	asks, bids := book.Depth(0)
+	require.Equal(t, []string{"0.0702"}, prices(asks))
+	require.Equal(t, []string{"0.07005", "0.07", "0.0699"}, prices(bids))
+
+	asks, bids = book.Depth(1)
+	require.Len(t, asks, 1)
+	require.Len(t, bids, 1)
+}
+
+func TestOrderBookResync(t *testing.T) {
+	defer server.Reset()
+	client := newWSClient(t)
+	defer client.Close()
+
+	book, err := hitbtc.NewOrderBook(client, "ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	defer book.Close()
+	require.Eventually(t, book.Synced, 5*time.Second, 10*time.Millisecond)
+
+	_, err = hitbtc.NewOrderBook(client, "ETHBTC")
+	require.Error(t, err, "one book per symbol")
+
+	changes := make(chan int64, 10)
+	book.OnChange(func(b *hitbtc.OrderBook) { changes <- b.Sequence() })
+
+	sequence := book.Sequence()
+	server.DropOrderbookUpdate("ETHBTC", nil, []hitbtc.OrderBookItem{level("0.07", "0")})
+	server.PublishOrderbookUpdate("ETHBTC", []hitbtc.OrderBookItem{level("0.0703", "2")}, nil)
+
+	select {
+	case seq := <-changes:
+		require.Equal(t, sequence+2, seq, "the gap is filled by a new snapshot")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the book was not resynchronized")
+	}
+	require.True(t, book.Synced())
+	bid, _ := book.BestBid()
+	require.Equal(t, "0.0699", bid.Price.String(), "the missed update is in the snapshot")
+	asks, _ := book.Depth(0)
+	require.Equal(t, []string{"0.0701", "0.0702", "0.0703"}, prices(asks))
+}
+
+func prices(levels []hitbtc.OrderBookItem) []string {
+	var prices []string
+	for _, level := range levels {
+		prices = append(prices, level.Price.String())
+	}
+	return prices
+}
+
+func TestOrderBookSharedSubscription(t *testing.T) {
+	defer server.Reset()
+	client := newWSClient(t)
+	defer client.Close()
+
+	book, err := hitbtc.NewOrderBook(client, "ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	defer book.Close()
+	require.Eventually(t, book.Synced, 5*time.Second, 10*time.Millisecond)
+
+	_, _, err = client.SubscribeOrderbook("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	require.NoError(t, client.UnsubscribeOrderbook("ETHBTC"), defaultErrorMessage)
+
+	sequence := book.Sequence()
+	server.PublishOrderbookUpdate("ETHBTC", []hitbtc.OrderBookItem{level("0.0703", "2")}, nil)
+	require.Eventually(t, func() bool { return book.Sequence() == sequence+1 }, 5*time.Second, 10*time.Millisecond, "the book still receives the updates")
+
+	events := client.ConnectionEvents()
+	waitState(t, events, hitbtc.Connected)
+	server.DropWSConnections()
+	waitState(t, events, hitbtc.Reconnecting)
+	waitState(t, events, hitbtc.Connected)
+	require.Eventually(t, book.Synced, 5*time.Second, 10*time.Millisecond, "the book subscription is replayed")
+	sequence = book.Sequence()
+	server.PublishOrderbookUpdate("ETHBTC", nil, []hitbtc.OrderBookItem{level("0.0698", "1")})
+	require.Eventually(t, func() bool { return book.Sequence() == sequence+1 }, 5*time.Second, 10*time.Millisecond)
+}
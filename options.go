@@ -0,0 +1,167 @@
+package hitbtc
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Logger is the interface used by the client to write debug output.
+// *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Option configures a HitBtc client.
+type Option func(*client)
+
+// WithHTTPClient sets the http client used to send requests.
+// Its Timeout, when set, becomes the request timeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.httpClient = httpClient
+		if httpClient.Timeout > 0 {
+			c.httpTimeout = httpClient.Timeout
+		}
+	}
+}
+
+// WithTimeout sets the timeout of a single request attempt.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.httpTimeout = timeout
+	}
+}
+
+// WithBaseURL sets the base URL of the REST API (API_BASE by default).
+func WithBaseURL(baseURL string) Option {
+	return func(c *client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithEnvironment sets the REST base URL from a preset such as Production or Demo.
+func WithEnvironment(env Environment) Option {
+	return WithBaseURL(env.APIBase)
+}
+
+// WithLogger sets the logger used for debug output.
+func WithLogger(logger Logger) Option {
+	return func(c *client) {
+		c.logger = logger
+	}
+}
+
+// WithDebug enables or disables http request/response dump.
+func WithDebug(enable bool) Option {
+	return func(c *client) {
+		c.debug = enable
+	}
+}
+
+// WithRateLimiter sets the limiter pacing requests. A nil limiter disables client-side rate limiting.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(c *client) {
+		c.limiter = limiter
+	}
+}
+
+// WithRetryPolicy sets how idempotent requests are retried on transient failures.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *client) {
+		c.retry = policy
+	}
+}
+
+// WithOrderNormalization makes PlaceOrder validate orders and round them to the
+// tick size and quantity increment of their symbol, following policy.
+// Symbols are fetched once and cached.
+func WithOrderNormalization(policy RoundingPolicy) Option {
+	return func(c *client) {
+		c.normalization = &policy
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *client) {
+		c.userAgent = userAgent
+	}
+}
+
+// wsConfig holds the settings of a WSClient.
+type wsConfig struct {
+	url       string
+	heartbeat time.Duration
+	reconnect *ReconnectPolicy
+	feeds     FeedConfig
+	feedKinds map[FeedKind]FeedConfig
+}
+
+// feedConfig returns the configuration of the feeds of kind.
+func (c *wsConfig) feedConfig(kind FeedKind) FeedConfig {
+	if config, ok := c.feedKinds[kind]; ok {
+		return config
+	}
+	return c.feeds
+}
+
+// WSOption configures a WSClient.
+type WSOption func(*wsConfig)
+
+// WithWSURL sets the URL of the websocket API (WS_API_BASE by default).
+func WithWSURL(url string) WSOption {
+	return func(c *wsConfig) {
+		c.url = url
+	}
+}
+
+// WithWSEnvironment sets the websocket URL from a preset such as Production or Demo.
+func WithWSEnvironment(env Environment) WSOption {
+	return WithWSURL(env.WSAPIBase)
+}
+
+// WithWSHeartbeat sets the interval of the pings checking that the connection
+// is alive (30 seconds by default). The connection is considered lost when no
+// pong is received within another interval. Zero disables the heartbeat.
+func WithWSHeartbeat(interval time.Duration) WSOption {
+	return func(c *wsConfig) {
+		c.heartbeat = interval
+	}
+}
+
+// WithWSReconnectPolicy sets the delays between the reconnection attempts after
+// the connection is lost (DefaultReconnectPolicy by default). A zero
+// MaxAttempts, ReconnectForever, retries until the client is closed.
+func WithWSReconnectPolicy(policy ReconnectPolicy) WSOption {
+	return func(c *wsConfig) {
+		c.reconnect = &policy
+	}
+}
+
+// WithoutWSReconnect disables the reconnection: the client is closed when the
+// connection is lost.
+func WithoutWSReconnect() WSOption {
+	return func(c *wsConfig) {
+		c.reconnect = nil
+	}
+}
+
+// WithWSFeeds sets the buffer size and slow-consumer policy of the channels of
+// every feed (DefaultFeedConfig by default).
+func WithWSFeeds(config FeedConfig) WSOption {
+	return func(c *wsConfig) {
+		c.feeds = config
+	}
+}
+
+// WithWSFeed sets the buffer size and slow-consumer policy of the channels of
+// the feeds of kind, overriding WithWSFeeds.
+func WithWSFeed(kind FeedKind, config FeedConfig) WSOption {
+	return func(c *wsConfig) {
+		if c.feedKinds == nil {
+			c.feedKinds = make(map[FeedKind]FeedConfig)
+		}
+		c.feedKinds[kind] = config
+	}
+}
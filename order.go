@@ -3,23 +3,30 @@ package hitbtc
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // Order represents an order made on the exchange.
 type Order struct {
-	ClientOrderId string    `json:"clientOrderId"`
-	Symbol        string    `json:"symbol"`
-	Side          string    `json:"side"`
-	Status        string    `json:"status"`
-	Type          string    `json:"type"`
-	TimeInForce   string    `json:"timeInForce"`
-	Quantity      float64   `json:"quantity,string"`
-	Price         float64   `json:"price,string"`
-	CumQuantity   float64   `json:"cumQuantity,string"`
-	Created       time.Time `json:"createdAt"`
-	Updated       time.Time `json:"updatedAt"`
-	StopPrice     float64   `json:"stopPrice,string"`
-	Expire        time.Time `json:"expireTime"`
+	ClientOrderId string          `json:"clientOrderId"`
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`
+	Status        string          `json:"status"`
+	Type          string          `json:"type"`
+	TimeInForce   string          `json:"timeInForce"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	Price         decimal.Decimal `json:"price"`
+	CumQuantity   decimal.Decimal `json:"cumQuantity"`
+	Created       time.Time       `json:"createdAt"`
+	Updated       time.Time       `json:"updatedAt"`
+	StopPrice     decimal.Decimal `json:"stopPrice"`
+	Expire        time.Time       `json:"expireTime"`
+}
+
+// RemainingQuantity returns the quantity not executed yet.
+func (t Order) RemainingQuantity() decimal.Decimal {
+	return t.Quantity.Sub(t.CumQuantity)
 }
 
 func (t *Order) UnmarshalJSON(data []byte) error {
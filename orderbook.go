@@ -2,18 +2,22 @@ package hitbtc
 
 import (
 	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // Orderbook represents an orderbook from hitbtc api.
 type Orderbook struct {
-	Ask []OrderBookItem `json:"ask,struct"`
-	Bid []OrderBookItem `json:"bid,struct"`
+	Ask       []OrderBookItem `json:"ask,struct"`
+	Bid       []OrderBookItem `json:"bid,struct"`
+	Timestamp time.Time       `json:"timestamp"`
 }
 
 // OrderBookItem for Ask and Bid field.
 type OrderBookItem struct {
-	Price float64 `json:"price,string"`
-	Size  float64 `json:"size,string"`
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
 }
 
 // UnmarshalJSON for OrderBook function
@@ -29,5 +33,8 @@ func (t *Orderbook) UnmarshalJSON(data []byte) error {
 	if err = json.Unmarshal(data, &aux); err != nil {
 		return err
 	}
-	return nil
+	if aux.Timestamp != "" {
+		t.Timestamp, err = time.Parse("2006-01-02T15:04:05.999Z", aux.Timestamp)
+	}
+	return err
 }
@@ -0,0 +1,122 @@
+package hitbtc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SortOrder is the order of the items returned by the history endpoints.
+type SortOrder string
+
+// Sort orders.
+const (
+	SortAsc  SortOrder = "ASC"
+	SortDesc SortOrder = "DESC"
+)
+
+// TradesBy selects how the From and Till filters of a TradesQuery apply.
+type TradesBy string
+
+// Trade filters.
+const (
+	TradesByTimestamp TradesBy = "timestamp" // filter with From and Till
+	TradesByID        TradesBy = "id"        // filter with FromID and TillID
+)
+
+// PublicTrade represents a trade made on a market.
+type PublicTrade struct {
+	Id        uint64          `json:"id"`
+	Price     decimal.Decimal `json:"price"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	Side      string          `json:"side"` // side of the taker: buy or sell
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// Value returns the traded amount in quote currency.
+func (t PublicTrade) Value() decimal.Decimal {
+	return t.Price.Mul(t.Quantity)
+}
+
+// UnmarshalJSON allows the obejct to be JSON Unmarshallable.
+func (t *PublicTrade) UnmarshalJSON(data []byte) error {
+	var err error
+	type Alias PublicTrade
+	aux := &struct {
+		Timestamp string `json:"timestamp"`
+		*Alias
+	}{
+		Alias: (*Alias)(t),
+	}
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	t.Timestamp, err = time.Parse("2006-01-02T15:04:05.999Z", aux.Timestamp)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// TradesQuery filters the public trades of a market. Zero fields are not sent
+// and the API defaults apply: newest trades first, filtered by timestamp,
+// 100 trades per request.
+type TradesQuery struct {
+	Sort   SortOrder
+	By     TradesBy
+	From   time.Time // used when By is TradesByTimestamp
+	Till   time.Time // used when By is TradesByTimestamp
+	FromID uint64    // used when By is TradesByID
+	TillID uint64    // used when By is TradesByID
+	Offset int
+	Limit  int // at most 1000
+}
+
+// params returns the request parameters of the query, as numbers and strings,
+// for the websocket API.
+func (q TradesQuery) params() map[string]interface{} {
+	params := make(map[string]interface{})
+	if q.Sort != "" {
+		params["sort"] = string(q.Sort)
+	}
+	if q.By != "" {
+		params["by"] = string(q.By)
+	}
+	if q.By == TradesByID {
+		if q.FromID > 0 {
+			params["from"] = q.FromID
+		}
+		if q.TillID > 0 {
+			params["till"] = q.TillID
+		}
+	} else {
+		if !q.From.IsZero() {
+			params["from"] = formatTime(q.From)
+		}
+		if !q.Till.IsZero() {
+			params["till"] = formatTime(q.Till)
+		}
+	}
+	if q.Offset > 0 {
+		params["offset"] = q.Offset
+	}
+	limit := q.Limit
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	if limit > 0 {
+		params["limit"] = limit
+	}
+	return params
+}
+
+// payload returns the request parameters of the query, for the REST API.
+func (q TradesQuery) payload() map[string]string {
+	payload := make(map[string]string)
+	for key, value := range q.params() {
+		payload[key] = fmt.Sprint(value)
+	}
+	return payload
+}
@@ -0,0 +1,134 @@
+package hitbtc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateCategory is a HitBTC rate limit category.
+type RateCategory int
+
+const (
+	// RateCategoryMarketData covers the public market data endpoints.
+	RateCategoryMarketData RateCategory = iota
+	// RateCategoryTrading covers the order endpoints.
+	RateCategoryTrading
+	// RateCategoryOther covers every other private endpoint.
+	RateCategoryOther
+)
+
+// RateLimit is the sustained rate (requests per second) and burst size of a category.
+// A zero Rate disables limiting for the category.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimits holds the limit of each rate category.
+type RateLimits struct {
+	MarketData RateLimit
+	Trading    RateLimit
+	Other      RateLimit
+}
+
+// DefaultRateLimits are the limits published by HitBTC.
+var DefaultRateLimits = RateLimits{
+	MarketData: RateLimit{Rate: 100, Burst: 100},
+	Trading:    RateLimit{Rate: 300, Burst: 300},
+	Other:      RateLimit{Rate: 10, Burst: 10},
+}
+
+// RateLimiter paces requests with a token bucket per rate category.
+// It can be shared between several clients using the same key or IP address.
+type RateLimiter struct {
+	marketData *tokenBucket
+	trading    *tokenBucket
+	other      *tokenBucket
+}
+
+// NewRateLimiter returns a RateLimiter enforcing limits.
+func NewRateLimiter(limits RateLimits) *RateLimiter {
+	return &RateLimiter{
+		marketData: newTokenBucket(limits.MarketData),
+		trading:    newTokenBucket(limits.Trading),
+		other:      newTokenBucket(limits.Other),
+	}
+}
+
+// Wait blocks until a request of the given category is allowed or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context, category RateCategory) error {
+	switch category {
+	case RateCategoryMarketData:
+		return l.marketData.wait(ctx)
+	case RateCategoryTrading:
+		return l.trading.wait(ctx)
+	default:
+		return l.other.wait(ctx)
+	}
+}
+
+// rateCategory returns the rate category of an API resource.
+func rateCategory(resource string) RateCategory {
+	switch {
+	case strings.HasPrefix(resource, "public/"):
+		return RateCategoryMarketData
+	case resource == "order" || strings.HasPrefix(resource, "order/"):
+		return RateCategoryTrading
+	default:
+		return RateCategoryOther
+	}
+}
+
+// tokenBucket is a token bucket refilled continuously at rate tokens per second.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// wait takes a token, sleeping until it is available.
+// The token is given back if ctx is done before that.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b.rate <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	deficit := -b.tokens
+	b.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(deficit / b.rate * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,45 @@
+package hitbtc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateCategory(t *testing.T) {
+	require.Equal(t, RateCategoryMarketData, rateCategory("public/ticker/ETHBTC"))
+	require.Equal(t, RateCategoryTrading, rateCategory("order"))
+	require.Equal(t, RateCategoryTrading, rateCategory("order/my-client-id"))
+	require.Equal(t, RateCategoryOther, rateCategory("trading/balance"))
+	require.Equal(t, RateCategoryOther, rateCategory("history/order"))
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := NewRateLimiter(RateLimits{Other: RateLimit{Rate: 20, Burst: 2}})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, limiter.Wait(ctx, RateCategoryOther))
+	}
+	// two requests fit in the burst, the two others wait 50ms each
+	require.True(t, time.Since(start) >= 90*time.Millisecond, "limiter did not block")
+
+	// categories without a rate are not limited
+	start = time.Now()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, limiter.Wait(ctx, RateCategoryMarketData))
+	}
+	require.True(t, time.Since(start) < 50*time.Millisecond)
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter := NewRateLimiter(RateLimits{Trading: RateLimit{Rate: 1, Burst: 1}})
+	require.NoError(t, limiter.Wait(context.Background(), RateCategoryTrading))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, limiter.Wait(ctx, RateCategoryTrading))
+}
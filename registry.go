@@ -0,0 +1,331 @@
+package hitbtc
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RegistryEventType is the kind of change detected by a Registry refresh.
+type RegistryEventType int
+
+const (
+	// SymbolListed is emitted when a new symbol appears.
+	SymbolListed RegistryEventType = iota
+	// SymbolDelisted is emitted when a symbol disappears.
+	SymbolDelisted
+	// SymbolChanged is emitted when the data of a symbol (tick size, fees...) changes.
+	SymbolChanged
+	// CurrencyListed is emitted when a new currency appears.
+	CurrencyListed
+	// CurrencyDelisted is emitted when a currency disappears.
+	CurrencyDelisted
+	// CurrencyChanged is emitted when the data of a currency changes.
+	CurrencyChanged
+)
+
+// RegistryEvent is a change detected by a Registry refresh.
+// Symbol is set for symbol events and Currency for currency events.
+type RegistryEvent struct {
+	Type     RegistryEventType
+	Symbol   Symbol
+	Currency Currency
+}
+
+// Registry caches the symbols and currencies of the exchange.
+// It is loaded on first use and refreshed on demand or periodically with Start.
+type Registry struct {
+	api *HitBtc
+
+	refreshMu sync.Mutex // serializes refreshes
+
+	deliveryMu sync.Mutex // guards pending and delivering
+	pending    []registryDelivery
+	delivering bool
+
+	mu         sync.RWMutex
+	loaded     bool
+	symbols    map[string]Symbol
+	currencies map[string]Currency
+	handlers   []func(RegistryEvent)
+	lastErr    error
+	stop       chan struct{}
+}
+
+// registryDelivery is the events of a refresh waiting for the handlers.
+type registryDelivery struct {
+	events   []RegistryEvent
+	handlers []func(RegistryEvent)
+}
+
+// NewRegistry returns an empty registry fetching its data with api.
+func NewRegistry(api *HitBtc) *Registry {
+	return &Registry{api: api}
+}
+
+// OnChange registers fn to be called for every change detected by a refresh.
+// The initial load emits no events.
+func (r *Registry) OnChange(fn func(RegistryEvent)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, fn)
+}
+
+// Refresh fetches the symbols and currencies and emits the detected changes.
+// The handlers are called once the refresh is done, so they may call Refresh,
+// and always in the order of the refreshes: when another goroutine is already
+// calling them, it emits the events of this refresh too, possibly after
+// Refresh returns.
+func (r *Registry) Refresh(ctx context.Context) error {
+	r.refreshMu.Lock()
+	events, handlers, err := r.refresh(ctx)
+	if len(events) > 0 {
+		r.deliveryMu.Lock()
+		r.pending = append(r.pending, registryDelivery{events, handlers})
+		r.deliveryMu.Unlock()
+	}
+	r.refreshMu.Unlock()
+
+	r.deliver()
+	return err
+}
+
+// deliver calls the handlers with the pending events, in order, unless another
+// goroutine is already doing it.
+func (r *Registry) deliver() {
+	r.deliveryMu.Lock()
+	if r.delivering {
+		r.deliveryMu.Unlock()
+		return
+	}
+	r.delivering = true
+
+	for len(r.pending) > 0 {
+		delivery := r.pending[0]
+		r.pending = r.pending[1:]
+		r.deliveryMu.Unlock()
+		for _, event := range delivery.events {
+			for _, handler := range delivery.handlers {
+				handler(event)
+			}
+		}
+		r.deliveryMu.Lock()
+	}
+	r.delivering = false
+	r.deliveryMu.Unlock()
+}
+
+// refresh does the work of Refresh and returns the events to emit to handlers.
+// r.refreshMu must be held.
+func (r *Registry) refresh(ctx context.Context) (events []RegistryEvent, handlers []func(RegistryEvent), err error) {
+	symbolList, err := r.api.GetSymbolsCtx(ctx)
+	if err != nil {
+		return
+	}
+	currencyList, err := r.api.GetCurrenciesCtx(ctx)
+	if err != nil {
+		return
+	}
+
+	symbols := make(map[string]Symbol, len(symbolList))
+	for _, symbol := range symbolList {
+		symbols[symbol.Id] = symbol
+	}
+	currencies := make(map[string]Currency, len(currencyList))
+	for _, currency := range currencyList {
+		currencies[currency.Id] = currency
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.loaded {
+		events = diffRegistry(r.symbols, symbols, r.currencies, currencies)
+	}
+	r.symbols = symbols
+	r.currencies = currencies
+	r.loaded = true
+	return events, r.handlers, nil
+}
+
+// diffRegistry returns the events turning the old symbols and currencies into the new ones.
+func diffRegistry(oldSymbols, newSymbols map[string]Symbol, oldCurrencies, newCurrencies map[string]Currency) []RegistryEvent {
+	var events []RegistryEvent
+	for id, symbol := range newSymbols {
+		previous, ok := oldSymbols[id]
+		switch {
+		case !ok:
+			events = append(events, RegistryEvent{Type: SymbolListed, Symbol: symbol})
+		case !symbolEqual(previous, symbol):
+			events = append(events, RegistryEvent{Type: SymbolChanged, Symbol: symbol})
+		}
+	}
+	for id, symbol := range oldSymbols {
+		if _, ok := newSymbols[id]; !ok {
+			events = append(events, RegistryEvent{Type: SymbolDelisted, Symbol: symbol})
+		}
+	}
+	for id, currency := range newCurrencies {
+		previous, ok := oldCurrencies[id]
+		switch {
+		case !ok:
+			events = append(events, RegistryEvent{Type: CurrencyListed, Currency: currency})
+		case previous != currency:
+			events = append(events, RegistryEvent{Type: CurrencyChanged, Currency: currency})
+		}
+	}
+	for id, currency := range oldCurrencies {
+		if _, ok := newCurrencies[id]; !ok {
+			events = append(events, RegistryEvent{Type: CurrencyDelisted, Currency: currency})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Type < events[j].Type })
+	return events
+}
+
+func symbolEqual(a, b Symbol) bool {
+	return a.Id == b.Id && a.BaseCurrency == b.BaseCurrency && a.QuoteCurrency == b.QuoteCurrency &&
+		a.FeeCurrency == b.FeeCurrency && a.QuantityIncrement.Equal(b.QuantityIncrement) &&
+		a.TickSize.Equal(b.TickSize) && a.TakeLiquidityRate.Equal(b.TakeLiquidityRate) &&
+		a.ProvideLiquidityRate.Equal(b.ProvideLiquidityRate)
+}
+
+// Load fetches the symbols and currencies unless they are already loaded.
+func (r *Registry) Load(ctx context.Context) error {
+	r.mu.RLock()
+	loaded := r.loaded
+	r.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	r.mu.RLock()
+	loaded = r.loaded
+	r.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+	// the initial load emits no events
+	_, _, err := r.refresh(ctx)
+	return err
+}
+
+// Start refreshes the registry every interval in the background, until ctx is done or Stop is called.
+// Refresh failures are retried at the next tick; see LastError.
+func (r *Registry) Start(ctx context.Context, interval time.Duration) {
+	stop := make(chan struct{})
+	r.mu.Lock()
+	if r.stop != nil {
+		close(r.stop)
+	}
+	r.stop = stop
+	r.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				err := r.Refresh(ctx)
+				r.mu.Lock()
+				r.lastErr = err
+				r.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stop stops the background refresh started by Start.
+func (r *Registry) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
+
+// LastError returns the error of the last background refresh, or nil if it succeeded.
+func (r *Registry) LastError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lastErr
+}
+
+// Symbol returns the symbol named id (ex: ETHBTC).
+func (r *Registry) Symbol(id string) (Symbol, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	symbol, ok := r.symbols[strings.ToUpper(id)]
+	return symbol, ok
+}
+
+// Symbols returns all the symbols, sorted by id.
+func (r *Registry) Symbols() []Symbol {
+	return r.filterSymbols(func(Symbol) bool { return true })
+}
+
+// SymbolsByBase returns the symbols trading the base currency, sorted by id.
+func (r *Registry) SymbolsByBase(currency string) []Symbol {
+	currency = strings.ToUpper(currency)
+	return r.filterSymbols(func(symbol Symbol) bool { return symbol.BaseCurrency == currency })
+}
+
+// SymbolsByQuote returns the symbols quoted in currency, sorted by id.
+func (r *Registry) SymbolsByQuote(currency string) []Symbol {
+	currency = strings.ToUpper(currency)
+	return r.filterSymbols(func(symbol Symbol) bool { return symbol.QuoteCurrency == currency })
+}
+
+func (r *Registry) filterSymbols(keep func(Symbol) bool) []Symbol {
+	r.mu.RLock()
+	symbols := make([]Symbol, 0, len(r.symbols))
+	for _, symbol := range r.symbols {
+		if keep(symbol) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Id < symbols[j].Id })
+	return symbols
+}
+
+// Currency returns the currency named id (ex: BTC).
+func (r *Registry) Currency(id string) (Currency, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	currency, ok := r.currencies[strings.ToUpper(id)]
+	return currency, ok
+}
+
+// Currencies returns all the currencies, sorted by id.
+func (r *Registry) Currencies() []Currency {
+	r.mu.RLock()
+	currencies := make([]Currency, 0, len(r.currencies))
+	for _, currency := range r.currencies {
+		currencies = append(currencies, currency)
+	}
+	r.mu.RUnlock()
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Id < currencies[j].Id })
+	return currencies
+}
+
+// lookupSymbol returns the symbol named id, loading the registry first if needed.
+func (r *Registry) lookupSymbol(ctx context.Context, id string) (Symbol, error) {
+	if err := r.Load(ctx); err != nil {
+		return Symbol{}, err
+	}
+	symbol, ok := r.Symbol(id)
+	if !ok {
+		return Symbol{}, &APIError{Code: ErrCodeSymbolNotFound, Message: "Symbol not found", Description: id}
+	}
+	return symbol, nil
+}
@@ -0,0 +1,117 @@
+package hitbtc_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	defer server.Reset()
+
+	registry := hitbtc.NewRegistry(hitbtc.New(apiKey, apiSecret, hitbtc.WithBaseURL(server.URL)))
+	var events []hitbtc.RegistryEvent
+	registry.OnChange(func(event hitbtc.RegistryEvent) { events = append(events, event) })
+
+	require.NoError(t, registry.Load(context.Background()), defaultErrorMessage)
+	require.Empty(t, events, "the initial load emits no events")
+
+	symbol, ok := registry.Symbol("ETHBTC")
+	require.True(t, ok)
+	require.Equal(t, "0.001", symbol.QuantityIncrement.String())
+	_, ok = registry.Currency("BTC")
+	require.True(t, ok)
+	require.Len(t, registry.SymbolsByBase("ETH"), 1)
+	require.Len(t, registry.SymbolsByQuote("BTC"), 1)
+
+	btcusd, _ := registry.Symbol("BTCUSD")
+	server.SetSymbols([]hitbtc.Symbol{
+		btcusd,
+		{Id: "ETHUSD", BaseCurrency: "ETH", QuoteCurrency: "USD", QuantityIncrement: decimal.RequireFromString("0.001"), TickSize: decimal.RequireFromString("0.01")},
+	})
+	require.NoError(t, registry.Refresh(context.Background()), defaultErrorMessage)
+
+	require.Len(t, events, 2)
+	require.Equal(t, hitbtc.SymbolListed, events[0].Type)
+	require.Equal(t, "ETHUSD", events[0].Symbol.Id)
+	require.Equal(t, hitbtc.SymbolDelisted, events[1].Type)
+	require.Equal(t, "ETHBTC", events[1].Symbol.Id)
+
+	_, ok = registry.Symbol("ETHBTC")
+	require.False(t, ok)
+	require.Len(t, registry.SymbolsByQuote("USD"), 2)
+}
+
+func TestRegistryHandlerRefreshes(t *testing.T) {
+	defer server.Reset()
+
+	registry := hitbtc.NewRegistry(hitbtc.New(apiKey, apiSecret, hitbtc.WithBaseURL(server.URL)))
+	require.NoError(t, registry.Load(context.Background()), defaultErrorMessage)
+
+	refreshed := make(chan error, 1)
+	registry.OnChange(func(event hitbtc.RegistryEvent) {
+		select {
+		case refreshed <- registry.Refresh(context.Background()):
+		default:
+		}
+	})
+
+	btcusd, _ := registry.Symbol("BTCUSD")
+	server.SetSymbols([]hitbtc.Symbol{btcusd})
+	done := make(chan error)
+	go func() { done <- registry.Refresh(context.Background()) }()
+	select {
+	case err := <-done:
+		require.NoError(t, err, defaultErrorMessage)
+	case <-time.After(5 * time.Second):
+		t.Fatal("a handler calling Refresh deadlocks")
+	}
+	require.NoError(t, <-refreshed, defaultErrorMessage)
+}
+
+func TestRegistryEventsInOrder(t *testing.T) {
+	defer server.Reset()
+
+	registry := hitbtc.NewRegistry(hitbtc.New(apiKey, apiSecret, hitbtc.WithBaseURL(server.URL)))
+	require.NoError(t, registry.Load(context.Background()), defaultErrorMessage)
+	btcusd, _ := registry.Symbol("BTCUSD")
+	ethusd := hitbtc.Symbol{Id: "ETHUSD", BaseCurrency: "ETH", QuoteCurrency: "USD", QuantityIncrement: decimal.RequireFromString("0.001"), TickSize: decimal.RequireFromString("0.01")}
+
+	// the handler is slow with the events of the first refresh
+	entered, release := make(chan struct{}), make(chan struct{})
+	var slow int32
+	var mu sync.Mutex
+	var types []hitbtc.RegistryEventType
+	registry.OnChange(func(event hitbtc.RegistryEvent) {
+		if event.Symbol.Id != "ETHUSD" {
+			return
+		}
+		if atomic.CompareAndSwapInt32(&slow, 0, 1) {
+			close(entered)
+			<-release
+		}
+		mu.Lock()
+		types = append(types, event.Type)
+		mu.Unlock()
+	})
+
+	server.SetSymbols([]hitbtc.Symbol{btcusd, ethusd})
+	first := make(chan error)
+	go func() { first <- registry.Refresh(context.Background()) }()
+	<-entered
+
+	server.SetSymbols([]hitbtc.Symbol{btcusd})
+	require.NoError(t, registry.Refresh(context.Background()), defaultErrorMessage)
+	close(release)
+	require.NoError(t, <-first, defaultErrorMessage)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []hitbtc.RegistryEventType{hitbtc.SymbolListed, hitbtc.SymbolDelisted}, types)
+}
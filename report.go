@@ -0,0 +1,71 @@
+package hitbtc
+
+import (
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+)
+
+// ReportType is the kind of change described by an execution report.
+type ReportType string
+
+// Report types.
+const (
+	ReportStatus    ReportType = "status" // state of an active order, sent in the snapshot
+	ReportNew       ReportType = "new"
+	ReportCanceled  ReportType = "canceled"
+	ReportRejected  ReportType = "rejected"
+	ReportExpired   ReportType = "expired"
+	ReportSuspended ReportType = "suspended"
+	ReportTrade     ReportType = "trade" // partial or complete fill; see IsPartialFill and IsFilled
+	ReportReplaced  ReportType = "replaced"
+)
+
+// Report is an execution report: the state of an order after a change.
+type Report struct {
+	Order
+	Id                           string          `json:"id"`
+	ReportType                   ReportType      `json:"reportType"`
+	OriginalRequestClientOrderId string          `json:"originalRequestClientOrderId"` // replaced order, for replaced reports
+	TradeId                      uint64          `json:"tradeId"`
+	TradeQuantity                decimal.Decimal `json:"tradeQuantity"`
+	TradePrice                   decimal.Decimal `json:"tradePrice"`
+	TradeFee                     decimal.Decimal `json:"tradeFee"`
+}
+
+// IsPartialFill reports whether the report is a trade leaving the order partially filled.
+func (r Report) IsPartialFill() bool {
+	return r.ReportType == ReportTrade && r.Status == "partiallyFilled"
+}
+
+// IsFilled reports whether the report is the trade completing the order.
+func (r Report) IsFilled() bool {
+	return r.ReportType == ReportTrade && r.Status == "filled"
+}
+
+// UnmarshalJSON allows the obejct to be JSON Unmarshallable.
+func (r *Report) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.Order); err != nil {
+		return err
+	}
+	aux := struct {
+		Id                           string          `json:"id"`
+		ReportType                   ReportType      `json:"reportType"`
+		OriginalRequestClientOrderId string          `json:"originalRequestClientOrderId"`
+		TradeId                      uint64          `json:"tradeId"`
+		TradeQuantity                decimal.Decimal `json:"tradeQuantity"`
+		TradePrice                   decimal.Decimal `json:"tradePrice"`
+		TradeFee                     decimal.Decimal `json:"tradeFee"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Id = aux.Id
+	r.ReportType = aux.ReportType
+	r.OriginalRequestClientOrderId = aux.OriginalRequestClientOrderId
+	r.TradeId = aux.TradeId
+	r.TradeQuantity = aux.TradeQuantity
+	r.TradePrice = aux.TradePrice
+	r.TradeFee = aux.TradeFee
+	return nil
+}
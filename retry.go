@@ -0,0 +1,120 @@
+package hitbtc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures how idempotent requests are retried on transient failures
+// (network errors, HTTP 429 and 5xx responses).
+//
+// Only GET requests and PUT order placement (keyed by clientOrderId) are retried;
+// POST and DELETE requests, such as withdrawals, are never retried. When a
+// retried placement is rejected because an earlier attempt placed the order,
+// that order is returned.
+type RetryPolicy struct {
+	MaxAttempts int           // total number of attempts, retries are disabled when lower than 2
+	MinBackoff  time.Duration // delay before the first retry
+	MaxBackoff  time.Duration // upper bound of the exponential delay
+	Jitter      float64       // random fraction, between 0 and 1, added to or removed from each delay
+}
+
+// DefaultRetryPolicy is the retry policy of new clients.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  250 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+	Jitter:      0.2,
+}
+
+// backoff returns the delay before the given retry (starting at 1).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(p.MinBackoff) * math.Pow(2, float64(retry-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// delay returns how long to wait before the given retry after err,
+// honoring the Retry-After hint of the server up to MaxBackoff.
+func (p RetryPolicy) delay(retry int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if p.MaxBackoff > 0 && apiErr.RetryAfter > p.MaxBackoff {
+			return p.MaxBackoff
+		}
+		return apiErr.RetryAfter
+	}
+	return p.backoff(retry)
+}
+
+// isIdempotent reports whether a request may be safely retried: a GET request,
+// or the placement of an order keyed by its clientOrderId (PUT order/{clientOrderId}),
+// which the exchange never executes twice.
+func isIdempotent(method string, resource string) bool {
+	switch method {
+	case http.MethodGet:
+		return true
+	case http.MethodPut:
+		clientOrderId := strings.TrimPrefix(resource, "order/")
+		return clientOrderId != resource && clientOrderId != "" && !strings.Contains(clientOrderId, "/")
+	}
+	return false
+}
+
+// isDuplicateClientOrderId reports whether err rejects an order whose
+// clientOrderId is already taken.
+func isDuplicateClientOrderId(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == ErrCodeDuplicateClientOrderId
+}
+
+// isRetryable reports whether err is a transient failure.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus == http.StatusTooManyRequests || apiErr.HTTPStatus >= 500
+	}
+	return true
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,103 @@
+package hitbtc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newFlakyServer(failures int32, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+}
+
+func TestRetryIdempotentRequests(t *testing.T) {
+	var calls int32
+	srv := newFlakyServer(2, &calls)
+	defer srv.Close()
+
+	c := NewClient("", "", WithRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+
+	response, err := c.do(context.Background(), "GET", srv.URL+"/public/symbol", nil, false)
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(response))
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls int32
+	srv := newFlakyServer(5, &calls)
+	defer srv.Close()
+
+	c := NewClient("", "", WithRetryPolicy(RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}))
+
+	_, err := c.do(context.Background(), "GET", srv.URL+"/public/symbol", nil, false)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusServiceUnavailable, apiErr.HTTPStatus)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestNoRetryForNonIdempotentRequests(t *testing.T) {
+	var calls int32
+	srv := newFlakyServer(1, &calls)
+	defer srv.Close()
+
+	c := NewClient("key", "secret", WithRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+
+	_, err := c.do(context.Background(), "POST", srv.URL+"/account/crypto/withdraw", nil, true)
+	require.Error(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetryOrderPlacement(t *testing.T) {
+	var calls int32
+	srv := newFlakyServer(1, &calls)
+	defer srv.Close()
+
+	c := NewClient("key", "secret", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+
+	_, err := c.do(context.Background(), "PUT", "order/my-order", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestNoRetryForOtherPutRequests(t *testing.T) {
+	var calls int32
+	srv := newFlakyServer(1, &calls)
+	defer srv.Close()
+
+	c := NewClient("key", "secret", WithBaseURL(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+
+	for _, resource := range []string{"account/transfer", "order/my-order/cancel", "order/"} {
+		atomic.StoreInt32(&calls, 0)
+		_, err := c.do(context.Background(), "PUT", resource, nil, true)
+		require.Error(t, err, resource)
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls), resource)
+	}
+}
+
+func TestRetryAfterCappedByMaxBackoff(t *testing.T) {
+	policy := RetryPolicy{MinBackoff: time.Millisecond, MaxBackoff: time.Second}
+	require.Equal(t, time.Second, policy.delay(1, &APIError{RetryAfter: time.Hour}))
+	require.Equal(t, 500*time.Millisecond, policy.delay(1, &APIError{RetryAfter: 500 * time.Millisecond}))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	require.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	require.Zero(t, parseRetryAfter(""))
+	require.Zero(t, parseRetryAfter("soon"))
+	d := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	require.True(t, d > 50*time.Second && d <= time.Minute)
+}
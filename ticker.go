@@ -3,22 +3,24 @@ package hitbtc
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Tickers []Ticker
 
 //Ticker represents a Ticker from hitbtc API.
 type Ticker struct {
-	Ask         float64   `json:"ask,string"`
-	Bid         float64   `json:"bid,string"`
-	Last        float64   `json:"last,string"`
-	Open        float64   `json:"open,string"`
-	Low         float64   `json:"low,string,omitempty"`
-	High        float64   `json:"high,string,omitempty"`
-	Volume      float64   `json:"volume,string"`
-	VolumeQuote float64   `json:"volumeQuote,string"`
-	Timestamp   time.Time `json:"timestamp"`
-	Symbol      string    `json:"symbol"`
+	Ask         decimal.Decimal `json:"ask"`
+	Bid         decimal.Decimal `json:"bid"`
+	Last        decimal.Decimal `json:"last"`
+	Open        decimal.Decimal `json:"open"`
+	Low         decimal.Decimal `json:"low"`
+	High        decimal.Decimal `json:"high"`
+	Volume      decimal.Decimal `json:"volume"`
+	VolumeQuote decimal.Decimal `json:"volumeQuote"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Symbol      string          `json:"symbol"`
 }
 
 func (t *Ticker) UnmarshalJSON(data []byte) error {
@@ -3,19 +3,26 @@ package hitbtc
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // Trade represents a single trade made by a user.
 type Trade struct {
-	Id            uint64    `json:"id"`
-	OrderId       uint64    `json:"orderId"`
-	ClientOrderId string    `json:"clientOrderId"`
-	Symbol        string    `json:"symbol"`
-	Type          string    `json:"side"`
-	Price         float64   `json:"price,string"`
-	Quantity      float64   `json:"quantity,string"`
-	Fee           float64   `json:"fee,string"`
-	Timestamp     time.Time `json:"timestamp"`
+	Id            uint64          `json:"id"`
+	OrderId       uint64          `json:"orderId"`
+	ClientOrderId string          `json:"clientOrderId"`
+	Symbol        string          `json:"symbol"`
+	Type          string          `json:"side"`
+	Price         decimal.Decimal `json:"price"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	Fee           decimal.Decimal `json:"fee"`
+	Timestamp     time.Time       `json:"timestamp"`
+}
+
+// Value returns the traded amount in quote currency, fee excluded.
+func (t Trade) Value() decimal.Decimal {
+	return t.Price.Mul(t.Quantity)
 }
 
 // UnmarshalJSON allows the obejct to be JSON Unmarshallable.
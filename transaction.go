@@ -3,22 +3,24 @@ package hitbtc
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // Transaction represents a transaction of money incoming or leaving the user account.
 type Transaction struct {
-	Id         string    `json:"id"`
-	Index      uint64    `json:"index"`
-	Currency   string    `json:"currency"`
-	Amount     float64   `json:"amount,string"`
-	Fee        float64   `json:"fee,string"`
-	NetworkFee float64   `json:"networkFee,string"`
-	Address    string    `json:"address"`
-	Hash       string    `json:"hash"`
-	Status     string    `json:"status"`
-	Type       string    `json:"type"`
-	Created    time.Time `json:"createdAt"`
-	Updated    time.Time `json:"updatedAt"`
+	Id         string          `json:"id"`
+	Index      uint64          `json:"index"`
+	Currency   string          `json:"currency"`
+	Amount     decimal.Decimal `json:"amount"`
+	Fee        decimal.Decimal `json:"fee"`
+	NetworkFee decimal.Decimal `json:"networkFee"`
+	Address    string          `json:"address"`
+	Hash       string          `json:"hash"`
+	Status     string          `json:"status"`
+	Type       string          `json:"type"`
+	Created    time.Time       `json:"createdAt"`
+	Updated    time.Time       `json:"updatedAt"`
 }
 
 func (t *Transaction) UnmarshalJSON(data []byte) error {
@@ -0,0 +1,119 @@
+package hitbtc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Order validation errors.
+var (
+	ErrInvalidSide     = errors.New("hitbtc: order side must be buy or sell")
+	ErrInvalidQuantity = errors.New("hitbtc: invalid order quantity")
+	ErrInvalidPrice    = errors.New("hitbtc: invalid order price")
+	ErrSymbolMismatch  = errors.New("hitbtc: order symbol does not match")
+)
+
+// RoundingMode is the direction used to round a value to a multiple of a step.
+type RoundingMode int
+
+const (
+	// RoundDown rounds toward zero.
+	RoundDown RoundingMode = iota
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundNearest rounds to the nearest multiple, halves away from zero.
+	RoundNearest
+)
+
+// RoundingPolicy sets how order prices and quantities are rounded to the symbol steps.
+type RoundingPolicy struct {
+	BuyPrice  RoundingMode
+	SellPrice RoundingMode
+	Quantity  RoundingMode
+}
+
+// DefaultRoundingPolicy never makes an order more aggressive than requested:
+// buy prices are rounded down, sell prices up and quantities down.
+var DefaultRoundingPolicy = RoundingPolicy{BuyPrice: RoundDown, SellPrice: RoundUp, Quantity: RoundDown}
+
+// roundToStep rounds a positive value to a multiple of step.
+func roundToStep(value, step decimal.Decimal, mode RoundingMode) decimal.Decimal {
+	if !step.IsPositive() {
+		return value
+	}
+	quotient, remainder := value.QuoRem(step, 0)
+	if remainder.IsZero() {
+		return value
+	}
+	switch mode {
+	case RoundUp:
+		quotient = quotient.Add(decimal.NewFromInt(1))
+	case RoundNearest:
+		if remainder.Mul(decimal.NewFromInt(2)).GreaterThanOrEqual(step) {
+			quotient = quotient.Add(decimal.NewFromInt(1))
+		}
+	}
+	return quotient.Mul(step)
+}
+
+// NormalizeOrder validates order against the symbol and returns a copy with its
+// price and stop price rounded to TickSize and its quantity rounded to
+// QuantityIncrement, following policy.
+//
+// The price is required for limit orders (and orders without type, which
+// HitBTC handles as limit orders) and the stop price for stop orders; the
+// returned errors wrap ErrInvalidSide,
+// ErrInvalidQuantity, ErrInvalidPrice or ErrSymbolMismatch.
+func (s Symbol) NormalizeOrder(order Order, policy RoundingPolicy) (Order, error) {
+	if order.Symbol != "" && !strings.EqualFold(order.Symbol, s.Id) {
+		return order, fmt.Errorf("%w: %s is not %s", ErrSymbolMismatch, order.Symbol, s.Id)
+	}
+	order.Symbol = s.Id
+
+	priceMode := policy.BuyPrice
+	switch order.Side {
+	case "buy":
+	case "sell":
+		priceMode = policy.SellPrice
+	default:
+		return order, fmt.Errorf("%w: %q", ErrInvalidSide, order.Side)
+	}
+
+	if !order.Quantity.IsPositive() {
+		return order, fmt.Errorf("%w: %s must be positive", ErrInvalidQuantity, order.Quantity)
+	}
+	quantity := roundToStep(order.Quantity, s.QuantityIncrement, policy.Quantity)
+	if !quantity.IsPositive() {
+		return order, fmt.Errorf("%w: %s is below the quantity increment %s", ErrInvalidQuantity, order.Quantity, s.QuantityIncrement)
+	}
+	order.Quantity = quantity
+
+	priceNeeded := order.Type == "" || order.Type == "limit" || order.Type == "stopLimit"
+	if priceNeeded || !order.Price.IsZero() {
+		if !order.Price.IsPositive() {
+			return order, fmt.Errorf("%w: %s must be positive", ErrInvalidPrice, order.Price)
+		}
+		price := roundToStep(order.Price, s.TickSize, priceMode)
+		if !price.IsPositive() {
+			return order, fmt.Errorf("%w: %s is below the tick size %s", ErrInvalidPrice, order.Price, s.TickSize)
+		}
+		order.Price = price
+	}
+
+	stopNeeded := order.Type == "stopLimit" || order.Type == "stopMarket"
+	if stopNeeded || !order.StopPrice.IsZero() {
+		if !order.StopPrice.IsPositive() {
+			return order, fmt.Errorf("%w: stop price %s must be positive", ErrInvalidPrice, order.StopPrice)
+		}
+		stopPrice := roundToStep(order.StopPrice, s.TickSize, priceMode)
+		if !stopPrice.IsPositive() {
+			return order, fmt.Errorf("%w: stop price %s is below the tick size %s", ErrInvalidPrice, order.StopPrice, s.TickSize)
+		}
+		order.StopPrice = stopPrice
+	}
+
+	return order, nil
+}
@@ -0,0 +1,86 @@
+package hitbtc_test
+
+import (
+	"errors"
+	"testing"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+var ethbtc = hitbtc.Symbol{
+	Id:                "ETHBTC",
+	BaseCurrency:      "ETH",
+	QuoteCurrency:     "BTC",
+	QuantityIncrement: decimal.RequireFromString("0.001"),
+	TickSize:          decimal.RequireFromString("0.000001"),
+}
+
+func TestNormalizeOrder(t *testing.T) {
+	tests := []struct {
+		side, price, quantity string
+		policy                hitbtc.RoundingPolicy
+		wantPrice, wantQty    string
+	}{
+		{"buy", "0.0701239", "1.2345", hitbtc.DefaultRoundingPolicy, "0.070123", "1.234"},
+		{"sell", "0.0701231", "1.2345", hitbtc.DefaultRoundingPolicy, "0.070124", "1.234"},
+		{"buy", "0.07", "2", hitbtc.DefaultRoundingPolicy, "0.07", "2"},
+		{"buy", "0.0701235", "1.2345", hitbtc.RoundingPolicy{BuyPrice: hitbtc.RoundNearest, Quantity: hitbtc.RoundUp}, "0.070124", "1.235"},
+	}
+	for _, test := range tests {
+		order, err := ethbtc.NormalizeOrder(hitbtc.Order{
+			Side:     test.side,
+			Type:     "limit",
+			Price:    decimal.RequireFromString(test.price),
+			Quantity: decimal.RequireFromString(test.quantity),
+		}, test.policy)
+		require.NoError(t, err, defaultErrorMessage)
+		require.Equal(t, "ETHBTC", order.Symbol)
+		require.Equal(t, test.wantPrice, order.Price.String())
+		require.Equal(t, test.wantQty, order.Quantity.String())
+	}
+}
+
+func TestNormalizeOrderRejects(t *testing.T) {
+	tests := []struct {
+		order hitbtc.Order
+		err   error
+	}{
+		{hitbtc.Order{Side: "hold", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(1)}, hitbtc.ErrInvalidSide},
+		{hitbtc.Order{Side: "buy", Quantity: decimal.Zero, Price: decimal.NewFromInt(1)}, hitbtc.ErrInvalidQuantity},
+		{hitbtc.Order{Side: "buy", Quantity: decimal.RequireFromString("0.0004"), Price: decimal.NewFromInt(1)}, hitbtc.ErrInvalidQuantity},
+		{hitbtc.Order{Side: "sell", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(-1)}, hitbtc.ErrInvalidPrice},
+		{hitbtc.Order{Side: "sell", Type: "limit", Quantity: decimal.NewFromInt(1)}, hitbtc.ErrInvalidPrice},
+		{hitbtc.Order{Symbol: "BTCUSD", Side: "buy", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(1)}, hitbtc.ErrSymbolMismatch},
+		{hitbtc.Order{Side: "buy", Type: "stopLimit", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(1), StopPrice: decimal.RequireFromString("0.0000001")}, hitbtc.ErrInvalidPrice},
+		{hitbtc.Order{Side: "buy", Type: "stopLimit", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(1)}, hitbtc.ErrInvalidPrice},
+		{hitbtc.Order{Side: "sell", Type: "stopMarket", Quantity: decimal.NewFromInt(1)}, hitbtc.ErrInvalidPrice},
+	}
+	for _, test := range tests {
+		_, err := ethbtc.NormalizeOrder(test.order, hitbtc.DefaultRoundingPolicy)
+		require.True(t, errors.Is(err, test.err), "expected %v, got %v", test.err, err)
+	}
+
+	_, err := ethbtc.NormalizeOrder(hitbtc.Order{Side: "buy", Type: "market", Quantity: decimal.NewFromInt(1)}, hitbtc.DefaultRoundingPolicy)
+	require.NoError(t, err, "market orders need no price")
+}
+
+func TestPlaceOrderNormalization(t *testing.T) {
+	defer server.Reset()
+
+	client := hitbtc.New(apiKey, apiSecret, hitbtc.WithBaseURL(server.URL), hitbtc.WithOrderNormalization(hitbtc.DefaultRoundingPolicy))
+	order, err := client.PlaceOrder(hitbtc.Order{
+		Symbol:   "ETHBTC",
+		Side:     "buy",
+		Type:     "limit",
+		Quantity: decimal.RequireFromString("1.23456"),
+		Price:    decimal.RequireFromString("0.07012345"),
+	})
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, "1.234", order.Quantity.String())
+	require.Equal(t, "0.070123", order.Price.String())
+
+	_, err = client.PlaceOrder(hitbtc.Order{Symbol: "ETHBTC", Side: "buy", Type: "limit", Quantity: decimal.RequireFromString("0.0001"), Price: decimal.NewFromInt(1)})
+	require.True(t, errors.Is(err, hitbtc.ErrInvalidQuantity), "got %v", err)
+}
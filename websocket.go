@@ -3,33 +3,50 @@ package hitbtc
 import (
 	"context"
 	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/juju/errors"
+	"github.com/shopspring/decimal"
 	jsonrpc2 "github.com/sourcegraph/jsonrpc2"
-	jsonrpc2ws "github.com/sourcegraph/jsonrpc2/websocket"
 )
 
-const wsAPIURL string = "wss://api.hitbtc.com/api/2/ws"
-
 // responseChannels handles all incoming data from the hitbtc connection.
 type responseChannels struct {
+	mu            sync.Mutex // guards the feed maps and books
 	notifications notificationChannels
 
-	OrderbookFeed map[string]chan WSNotificationOrderbookSnapshot
-	TradesFeed    map[string]chan WSNotificationTradesSnapshot
-	CandlesFeed   map[string]chan WSNotificationCandlesSnapshot
+	OrderbookFeed map[string]*feed[WSNotificationOrderbookSnapshot]
+	TradesFeed    map[string]*feed[WSNotificationTradesSnapshot]
+	CandlesFeed   map[candlesKey]*feed[WSNotificationCandlesSnapshot]
+
+	ErrorFeed *feed[error]
+
+	books map[string]*OrderBook // local order books maintained from the orderbook notifications
 
-	ErrorFeed chan error
+	dropped map[string]*uint64 // notifications discarded by the slow-consumer policies, by feed name
+
+	handlers wsHandlers   // callbacks registered with the On methods
+	events   *feed[Event] // merged stream returned by Events
+
+	reports         *feed[Report]
+	reportsSnapshot chan []Report // receives the activeOrders snapshot awaited by SubscribeReports
 }
 
 // notificationChannels contains all the notifications from hitbtc for subscribed feeds.
 type notificationChannels struct {
-	TickerFeed    map[string]chan WSNotificationTickerResponse
-	OrderbookFeed map[string]chan WSNotificationOrderbookUpdate
-	TradesFeed    map[string]chan WSNotificationTradesUpdate
-	CandlesFeed   map[string]chan WSNotificationCandlesUpdate
+	TickerFeed    map[string]*feed[WSNotificationTickerResponse]
+	OrderbookFeed map[string]*feed[WSNotificationOrderbookUpdate]
+	TradesFeed    map[string]*feed[WSNotificationTradesUpdate]
+	CandlesFeed   map[candlesKey]*feed[WSNotificationCandlesUpdate]
+}
+
+// candlesKey identifies a candles subscription: a market may be followed in several periods at once.
+type candlesKey struct {
+	symbol string
+	period Period
 }
 
 // Handle handles all incoming connections and fills the channels properly.
@@ -41,132 +58,463 @@ func (h *responseChannels) Handle(ctx context.Context, conn *jsonrpc2.Conn, req
 			var msg WSNotificationTickerResponse
 			err := json.Unmarshal(message, &msg)
 			if err != nil {
-				h.ErrorFeed <- err
+				h.reportError(err)
 			} else {
-				h.notifications.TickerFeed[msg.Symbol] <- msg
+				h.mu.Lock()
+				feed := h.notifications.TickerFeed[msg.Symbol]
+				handlers := h.handlers.ticker[msg.Symbol]
+				events := h.events
+				h.mu.Unlock()
+				if feed != nil {
+					feed.send(msg)
+				}
+				for _, handler := range handlers {
+					(*handler)(msg)
+				}
+				if events != nil {
+					events.send(TickerEvent{msg})
+				}
 			}
 		case "snapshotOrderbook":
 			var msg WSNotificationOrderbookSnapshot
 			err := json.Unmarshal(message, &msg)
 			if err != nil {
-				h.ErrorFeed <- err
+				h.reportError(err)
 			} else {
-				h.OrderbookFeed[msg.Symbol] <- msg
+				h.mu.Lock()
+				feed := h.OrderbookFeed[msg.Symbol]
+				book := h.books[msg.Symbol]
+				handlers := h.handlers.orderbook[msg.Symbol]
+				events := h.events
+				h.mu.Unlock()
+				if book != nil {
+					book.applySnapshot(msg)
+				}
+				if feed != nil {
+					feed.send(msg)
+				}
+				for _, handler := range handlers {
+					(*handler)(true, msg.Ask, msg.Bid, msg.Sequence)
+				}
+				if events != nil {
+					events.send(OrderbookEvent{msg.Symbol, true, msg.Ask, msg.Bid, msg.Sequence})
+				}
 			}
 		case "updateOrderbook":
 			var msg WSNotificationOrderbookUpdate
 			err := json.Unmarshal(message, &msg)
 			if err != nil {
-				h.ErrorFeed <- err
+				h.reportError(err)
+			} else {
+				h.mu.Lock()
+				feed := h.notifications.OrderbookFeed[msg.Symbol]
+				book := h.books[msg.Symbol]
+				handlers := h.handlers.orderbook[msg.Symbol]
+				events := h.events
+				h.mu.Unlock()
+				if book != nil {
+					book.applyUpdate(msg)
+				}
+				if feed != nil {
+					feed.send(msg)
+				}
+				for _, handler := range handlers {
+					(*handler)(false, msg.Ask, msg.Bid, msg.Sequence)
+				}
+				if events != nil {
+					events.send(OrderbookEvent{msg.Symbol, false, msg.Ask, msg.Bid, msg.Sequence})
+				}
+			}
+		case "activeOrders":
+			var msg []Report
+			err := json.Unmarshal(message, &msg)
+			if err != nil {
+				h.reportError(err)
 			} else {
-				h.notifications.OrderbookFeed[msg.Symbol] <- msg
+				h.mu.Lock()
+				feed := h.reports
+				snapshot := h.reportsSnapshot
+				h.reportsSnapshot = nil
+				events := h.events
+				h.mu.Unlock()
+				if snapshot != nil {
+					snapshot <- msg
+				} else if feed != nil {
+					// snapshot of a replayed subscription, after a reconnection
+					for _, report := range msg {
+						feed.send(report)
+					}
+				}
+				if events != nil {
+					for _, report := range msg {
+						events.send(ReportEvent{report})
+					}
+				}
+			}
+		case "report":
+			var msg Report
+			err := json.Unmarshal(message, &msg)
+			if err != nil {
+				h.reportError(err)
+			} else {
+				h.mu.Lock()
+				feed := h.reports
+				events := h.events
+				h.mu.Unlock()
+				if feed != nil {
+					feed.send(msg)
+				}
+				if events != nil {
+					events.send(ReportEvent{msg})
+				}
 			}
 		case "snapshotTrades":
 			var msg WSNotificationTradesSnapshot
 			err := json.Unmarshal(message, &msg)
 			if err != nil {
-				h.ErrorFeed <- err
+				h.reportError(err)
 			} else {
-				h.TradesFeed[msg.Symbol] <- msg
+				h.mu.Lock()
+				feed := h.TradesFeed[msg.Symbol]
+				handlers := h.handlers.trades[msg.Symbol]
+				events := h.events
+				h.mu.Unlock()
+				if feed != nil {
+					feed.send(msg)
+				}
+				for _, handler := range handlers {
+					(*handler)(true, msg.Data)
+				}
+				if events != nil {
+					events.send(TradesEvent{msg.Symbol, true, msg.Data})
+				}
 			}
 		case "updateTrades":
 			var msg WSNotificationTradesUpdate
 			err := json.Unmarshal(message, &msg)
 			if err != nil {
-				h.ErrorFeed <- err
+				h.reportError(err)
 			} else {
-				h.notifications.TradesFeed[msg.Symbol] <- msg
+				h.mu.Lock()
+				feed := h.notifications.TradesFeed[msg.Symbol]
+				handlers := h.handlers.trades[msg.Symbol]
+				events := h.events
+				h.mu.Unlock()
+				if feed != nil {
+					feed.send(msg)
+				}
+				trades := []WSTrades{msg.Data}
+				for _, handler := range handlers {
+					(*handler)(false, trades)
+				}
+				if events != nil {
+					events.send(TradesEvent{msg.Symbol, false, trades})
+				}
 			}
 		case "snapshotCandles":
 			var msg WSNotificationCandlesSnapshot
 			err := json.Unmarshal(message, &msg)
 			if err != nil {
-				h.ErrorFeed <- err
+				h.reportError(err)
 			} else {
-				h.CandlesFeed[msg.Symbol] <- msg
+				key := candlesKey{msg.Symbol, msg.Period}
+				h.mu.Lock()
+				feed := h.CandlesFeed[key]
+				handlers := h.handlers.candles[key]
+				events := h.events
+				h.mu.Unlock()
+				if feed != nil {
+					feed.send(msg)
+				}
+				for _, handler := range handlers {
+					(*handler)(true, msg.Data)
+				}
+				if events != nil {
+					events.send(CandlesEvent{msg.Symbol, msg.Period, true, msg.Data})
+				}
 			}
 		case "updateCandles":
 			var msg WSNotificationCandlesUpdate
 			err := json.Unmarshal(message, &msg)
 			if err != nil {
-				h.ErrorFeed <- err
+				h.reportError(err)
 			} else {
-				h.notifications.CandlesFeed[msg.Symbol] <- msg
+				key := candlesKey{msg.Symbol, msg.Period}
+				h.mu.Lock()
+				feed := h.notifications.CandlesFeed[key]
+				handlers := h.handlers.candles[key]
+				events := h.events
+				h.mu.Unlock()
+				if feed != nil {
+					feed.send(msg)
+				}
+				candles := []WSCandles{msg.Data}
+				for _, handler := range handlers {
+					(*handler)(false, candles)
+				}
+				if events != nil {
+					events.send(CandlesEvent{msg.Symbol, msg.Period, false, candles})
+				}
 			}
 		}
 	}
 }
 
+// reportError passes err to ErrorFeed; it never blocks.
+func (h *responseChannels) reportError(err error) {
+	h.mu.Lock()
+	errors := h.ErrorFeed
+	h.mu.Unlock()
+	errors.send(err)
+}
+
+// desyncBooks marks the local order books out of sync until their next snapshot.
+func (h *responseChannels) desyncBooks() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, book := range h.books {
+		book.desync()
+	}
+}
+
+// orderedHandler passes the incoming messages to a handler one at a time and in
+// order of arrival. Up to maxQueuedMessages messages are queued so that the
+// connection keeps reading the replies while a message is handled; past that,
+// the connection stops reading until the handler catches up, which is how a
+// blocked feed applies backpressure to the socket. See heartbeatHandler for
+// the heartbeat of a stalled connection.
+type orderedHandler struct {
+	handler jsonrpc2.Handler
+	queue   chan orderedRequest
+	done    chan struct{}
+}
+
+// maxQueuedMessages is the number of incoming messages an orderedHandler queues.
+const maxQueuedMessages = 1024
+
+type orderedRequest struct {
+	ctx  context.Context
+	conn *jsonrpc2.Conn
+	req  *jsonrpc2.Request
+}
+
+func newOrderedHandler(handler jsonrpc2.Handler) *orderedHandler {
+	h := &orderedHandler{handler: handler, queue: make(chan orderedRequest, maxQueuedMessages), done: make(chan struct{})}
+	go h.run()
+	return h
+}
+
+// Handle queues the message, waiting for room if the queue is full.
+func (h *orderedHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	h.enqueue(orderedRequest{ctx, conn, req}, 0, nil)
+}
+
+// enqueue queues r, waiting for room if the queue is full. If stalled is set,
+// it is called when the wait starts and then every interval until it ends.
+func (h *orderedHandler) enqueue(r orderedRequest, interval time.Duration, stalled func()) {
+	select {
+	case h.queue <- r:
+		return
+	case <-h.done:
+		return
+	default:
+	}
+
+	var tick <-chan time.Time
+	if stalled != nil {
+		stalled()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case h.queue <- r:
+			return
+		case <-h.done:
+			return
+		case <-tick:
+			stalled()
+		}
+	}
+}
+
+func (h *orderedHandler) run() {
+	for {
+		select {
+		case <-h.done:
+			return
+		case r := <-h.queue:
+			h.handler.Handle(r.ctx, r.conn, r.req)
+		}
+	}
+}
+
+// close stops the handling of the messages.
+func (h *orderedHandler) close() {
+	close(h.done)
+}
+
 // WSClient represents a JSON RPC v2 Connection over Websocket,
+//
+// The client reconnects automatically when the connection is lost, logging in
+// again and replaying the active subscriptions; see ConnectionEvents.
 type WSClient struct {
-	conn    *jsonrpc2.Conn
-	updates *responseChannels
+	config     wsConfig
+	updates    *responseChannels
+	dispatcher *orderedHandler
+
+	subMu sync.Mutex // serializes the subscribe and unsubscribe calls
+
+	mu            sync.Mutex // guards conn, subscriptions, refs, relogin and closed
+	conn          *jsonrpc2.Conn
+	subscriptions map[string]wsSubscription
+	refs          map[string]int // references on the subscriptions, see subscriptionCall
+	relogin       func(ctx context.Context, conn *jsonrpc2.Conn) error
+	closed        bool
+	done          chan struct{}
+
+	eventsMu     sync.Mutex
+	events       chan ConnectionEvent
+	eventsClosed bool
 }
 
-// NewWSClient creates a new WSClient
-func NewWSClient() (*WSClient, error) {
-	conn, _, err := websocket.DefaultDialer.Dial(wsAPIURL, nil)
-	if err != nil {
-		return nil, err
+// NewWSClient creates a new WSClient configured by opts
+func NewWSClient(opts ...WSOption) (*WSClient, error) {
+	reconnect := DefaultReconnectPolicy
+	config := wsConfig{url: WS_API_BASE, heartbeat: defaultHeartbeat, reconnect: &reconnect, feeds: DefaultFeedConfig}
+	for _, opt := range opts {
+		opt(&config)
 	}
 
 	handler := responseChannels{
 		notifications: notificationChannels{
-			TickerFeed:    make(map[string]chan WSNotificationTickerResponse),
-			OrderbookFeed: make(map[string]chan WSNotificationOrderbookUpdate),
-			TradesFeed:    make(map[string]chan WSNotificationTradesUpdate),
-			CandlesFeed:   make(map[string]chan WSNotificationCandlesUpdate),
+			TickerFeed:    make(map[string]*feed[WSNotificationTickerResponse]),
+			OrderbookFeed: make(map[string]*feed[WSNotificationOrderbookUpdate]),
+			TradesFeed:    make(map[string]*feed[WSNotificationTradesUpdate]),
+			CandlesFeed:   make(map[candlesKey]*feed[WSNotificationCandlesUpdate]),
 		},
 
-		OrderbookFeed: make(map[string]chan WSNotificationOrderbookSnapshot),
-		TradesFeed:    make(map[string]chan WSNotificationTradesSnapshot),
-		CandlesFeed:   make(map[string]chan WSNotificationCandlesSnapshot),
+		OrderbookFeed: make(map[string]*feed[WSNotificationOrderbookSnapshot]),
+		TradesFeed:    make(map[string]*feed[WSNotificationTradesSnapshot]),
+		CandlesFeed:   make(map[candlesKey]*feed[WSNotificationCandlesSnapshot]),
 
-		ErrorFeed: make(chan error),
-	}
+		ErrorFeed: newFeed[error](feedOptions{FeedConfig: errorsFeedConfig}),
 
-	return &WSClient{
-		conn:    jsonrpc2.NewConn(context.Background(), jsonrpc2ws.NewObjectStream(conn), jsonrpc2.AsyncHandler(&handler)),
-		updates: &handler,
-	}, nil
+		books:   make(map[string]*OrderBook),
+		dropped: make(map[string]*uint64),
+
+		handlers: newWSHandlers(),
+	}
+	c := &WSClient{
+		config:        config,
+		updates:       &handler,
+		dispatcher:    newOrderedHandler(&handler),
+		subscriptions: make(map[string]wsSubscription),
+		refs:          make(map[string]int),
+		done:          make(chan struct{}),
+		events:        make(chan ConnectionEvent, 16),
+	}
+	conn, err := c.dial()
+	if err != nil {
+		c.dispatcher.close()
+		return nil, err
+	}
+	c.conn = conn
+	go c.watch(conn)
+	c.emit(ConnectionEvent{State: Connected})
+	return c, nil
 }
 
 // Close closes the Websocket connected to the hitbtc api.
 func (c *WSClient) Close() {
-	c.conn.Close()
+	c.shutdown(nil)
+}
 
-	for _, channel := range c.updates.notifications.TickerFeed {
-		close(channel)
-	}
-	for _, channel := range c.updates.notifications.TradesFeed {
-		close(channel)
+// shutdown closes the client and its feeds, reporting err as the cause.
+func (c *WSClient) shutdown(err error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
 	}
-	for _, channel := range c.updates.notifications.CandlesFeed {
-		close(channel)
+	c.closed = true
+	close(c.done)
+	conn := c.conn
+	c.mu.Unlock()
+
+	conn.Close()
+	c.dispatcher.close()
+	c.closeFeeds()
+	c.emit(ConnectionEvent{State: Closed, Err: err})
+}
+
+// closeFeeds closes the channels of every feed.
+func (c *WSClient) closeFeeds() {
+	c.updates.mu.Lock()
+	defer c.updates.mu.Unlock()
+
+	closeAll(c.updates.notifications.TickerFeed)
+	closeAll(c.updates.notifications.TradesFeed)
+	closeAll(c.updates.notifications.CandlesFeed)
+	closeAll(c.updates.notifications.OrderbookFeed)
+	closeAll(c.updates.OrderbookFeed)
+	closeAll(c.updates.TradesFeed)
+	closeAll(c.updates.CandlesFeed)
+
+	if c.updates.reports != nil {
+		c.updates.reports.close()
+		c.updates.reports = nil
 	}
-	for _, channel := range c.updates.notifications.OrderbookFeed {
-		close(channel)
+	if c.updates.events != nil {
+		c.updates.events.close()
+		c.updates.events = nil
 	}
-	for _, channel := range c.updates.OrderbookFeed {
-		close(channel)
+	c.updates.ErrorFeed.close()
+	c.updates.books = make(map[string]*OrderBook)
+	c.updates.handlers = newWSHandlers()
+}
+
+// Errors returns the channel of the errors met while handling the notifications,
+// such as undecodable messages or failed order book resyncs. Errors are dropped,
+// oldest first, if the channel is not read. It is closed when the client is closed.
+func (c *WSClient) Errors() <-chan error {
+	return c.updates.ErrorFeed.ch
+}
+
+// Dropped returns the number of notifications discarded by the slow-consumer
+// policies since the client was created, by feed: "ticker:ETHBTC",
+// "orderbook:ETHBTC", "trades:ETHBTC", "candles:ETHBTC:M30" or "reports".
+func (c *WSClient) Dropped() map[string]uint64 {
+	c.updates.mu.Lock()
+	defer c.updates.mu.Unlock()
+	dropped := make(map[string]uint64, len(c.updates.dropped))
+	for name, n := range c.updates.dropped {
+		if n := atomic.LoadUint64(n); n > 0 {
+			dropped[name] = n
+		}
 	}
-	for _, channel := range c.updates.TradesFeed {
-		close(channel)
+	return dropped
+}
+
+// newFeedOptions returns the settings of the feeds of a subscription named name;
+// unsubscribe ends the subscription for the Disconnect policy. c.updates.mu must be held.
+func (c *WSClient) newFeedOptions(kind FeedKind, name string, unsubscribe func() error) feedOptions {
+	dropped := c.updates.dropped[name]
+	if dropped == nil {
+		dropped = new(uint64)
+		c.updates.dropped[name] = dropped
 	}
-	for _, channel := range c.updates.CandlesFeed {
-		close(channel)
+	return feedOptions{
+		FeedConfig: c.config.feedConfig(kind),
+		dropped:    dropped,
+		evict: func() {
+			c.updates.reportError(errors.Annotatef(ErrSlowConsumer, "Hitbtc %s", name))
+			if err := unsubscribe(); err != nil {
+				c.updates.reportError(err)
+			}
+		},
 	}
-
-	close(c.updates.ErrorFeed)
-
-	c.updates.notifications.TickerFeed = make(map[string]chan WSNotificationTickerResponse)
-	c.updates.notifications.TradesFeed = make(map[string]chan WSNotificationTradesUpdate)
-	c.updates.notifications.OrderbookFeed = make(map[string]chan WSNotificationOrderbookUpdate)
-	c.updates.notifications.CandlesFeed = make(map[string]chan WSNotificationCandlesUpdate)
-	c.updates.CandlesFeed = make(map[string]chan WSNotificationCandlesSnapshot)
-	c.updates.TradesFeed = make(map[string]chan WSNotificationTradesSnapshot)
-	c.updates.OrderbookFeed = make(map[string]chan WSNotificationOrderbookSnapshot)
-	c.updates.ErrorFeed = make(chan error)
 }
 
 // WSGetCurrencyRequest is get currency request type on websocket
@@ -176,31 +524,45 @@ type WSGetCurrencyRequest struct {
 
 // WSGetCurrencyResponse is get currency response type on websocket
 type WSGetCurrencyResponse struct {
-	ID                 string `json:"id,required"`
-	FullName           string `json:"fullname,required"`
-	Crypto             bool   `json:"crypto,required"`
-	PayinEnabled       bool   `json:"payinEnabled,required"`
-	PayinPaymentID     bool   `json:"payinPaymentId,required"`
-	PayinConfirmations int    `json:"payinConfirmations,required"`
-	PayoutEnabled      bool   `json:"payoutEnabled,required"`
-	PayoutIsPaymentID  bool   `json:"payoutIsPaymentId,required"`
-	TransferEnabled    bool   `json:"transferEnabled,required"`
-	Delisted           bool   `json:"delisted,required"`
-	PayoutFee          string `json:"payoutFee,required"`
+	ID                 string          `json:"id,required"`
+	FullName           string          `json:"fullname,required"`
+	Crypto             bool            `json:"crypto,required"`
+	PayinEnabled       bool            `json:"payinEnabled,required"`
+	PayinPaymentID     bool            `json:"payinPaymentId,required"`
+	PayinConfirmations int             `json:"payinConfirmations,required"`
+	PayoutEnabled      bool            `json:"payoutEnabled,required"`
+	PayoutIsPaymentID  bool            `json:"payoutIsPaymentId,required"`
+	TransferEnabled    bool            `json:"transferEnabled,required"`
+	Delisted           bool            `json:"delisted,required"`
+	PayoutFee          decimal.Decimal `json:"payoutFee,required"`
 }
 
 // GetCurrencyInfo get the info about a currency.
+//
+// Deprecated: use GetCurrencyCtx, which honors a context and returns the
+// Currency model of the REST client.
 func (c *WSClient) GetCurrencyInfo(symbol string) (*WSGetCurrencyResponse, error) {
 	var request = WSGetCurrencyRequest{Currency: symbol}
 	var response WSGetCurrencyResponse
 
-	err := c.conn.Call(context.Background(), "getCurrency", request, &response)
+	err := c.call(context.Background(), "getCurrency", request, &response)
 	if err != nil {
 		return nil, errors.Annotate(err, "Hitbtc GetCurrency")
 	}
 	return &response, nil
 }
 
+// GetCurrencyCtx obtains the data of a currency, as GetCurrencies of the REST client.
+func (c *WSClient) GetCurrencyCtx(ctx context.Context, currency string) (Currency, error) {
+	var response Currency
+
+	err := c.call(ctx, "getCurrency", WSGetCurrencyRequest{Currency: currency}, &response)
+	if err != nil {
+		return Currency{}, errors.Annotate(err, "Hitbtc GetCurrency")
+	}
+	return response, nil
+}
+
 // WSGetSymbolRequest is get symbols request type on websocket
 type WSGetSymbolRequest struct {
 	Symbol string `json:"symbol,required"`
@@ -208,54 +570,93 @@ type WSGetSymbolRequest struct {
 
 // WSGetSymbolResponse is get symbols response type on websocket
 type WSGetSymbolResponse struct {
-	ID                   string `json:"id,required"`
-	BaseCurrency         string `json:"baseCurrency,required"`
-	QuoteCurrency        string `json:"quoteCurrency,required"`
-	QuantityIncrement    string `json:"quantityIncrement,required"`
-	TickSize             string `json:"tickSize,required"`
-	TakeLiquidityRate    string `json:"takeLiquidityRate,required"`
-	ProvideLiquidityRate string `json:"provideLiquidityRate,required"`
-	FeeCurrency          string `json:"feeCurrency,required"`
+	ID                   string          `json:"id,required"`
+	BaseCurrency         string          `json:"baseCurrency,required"`
+	QuoteCurrency        string          `json:"quoteCurrency,required"`
+	QuantityIncrement    decimal.Decimal `json:"quantityIncrement,required"`
+	TickSize             decimal.Decimal `json:"tickSize,required"`
+	TakeLiquidityRate    decimal.Decimal `json:"takeLiquidityRate,required"`
+	ProvideLiquidityRate decimal.Decimal `json:"provideLiquidityRate,required"`
+	FeeCurrency          string          `json:"feeCurrency,required"`
 }
 
 // GetSymbol obtains the data of a market.
+//
+// Deprecated: use GetSymbolCtx, which honors a context and returns the Symbol
+// model of the REST client.
 func (c *WSClient) GetSymbol(symbol string) (*WSGetSymbolResponse, error) {
 	var request = WSGetSymbolRequest{Symbol: symbol}
 	var response WSGetSymbolResponse
 
-	err := c.conn.Call(context.Background(), "getSymbol", request, &response)
+	err := c.call(context.Background(), "getSymbol", request, &response)
 	if err != nil {
 		return nil, errors.Annotate(err, "Hitbtc GetSymbol")
 	}
 	return &response, nil
 }
 
-// WSGetTradesRequest is get trades request type on websocket
+// GetSymbolCtx obtains the data of a market, as GetSymbols of the REST client.
+func (c *WSClient) GetSymbolCtx(ctx context.Context, symbol string) (Symbol, error) {
+	var response Symbol
+
+	err := c.call(ctx, "getSymbol", WSGetSymbolRequest{Symbol: symbol}, &response)
+	if err != nil {
+		return Symbol{}, errors.Annotate(err, "Hitbtc GetSymbol")
+	}
+	return response, nil
+}
+
+// WSGetTradesRequest is get trades request type on websocket: the trades of
+// Symbol selected by a TradesQuery, as for the REST API.
 type WSGetTradesRequest struct {
-	Symbol string     `json:"symbol,required"`
-	Limit  int        `json:"limit,required"`
-	Sort   string     `json:"sort,required"`
-	By     string     `json:"by,required"`
-	From   *time.Time `json:"from,omitempty"`
-	Till   *time.Time `json:"till,omitempty"`
-	Offset *string    `json:"offset,omitempty"`
+	Symbol string
+	TradesQuery
+}
+
+// MarshalJSON sends the symbol with the parameters of the query.
+func (r WSGetTradesRequest) MarshalJSON() ([]byte, error) {
+	params := r.TradesQuery.params()
+	params["symbol"] = r.Symbol
+	return json.Marshal(params)
 }
 
 // WSGetTradesResponse  is get symbols response type on websocket
 type WSGetTradesResponse struct {
-	Data []WSTrades `json:"data,required"`
+	Data   []WSTrades `json:"data,required"`
+	Symbol string     `json:"symbol"`
 }
 
 // GetTrades obtains the data of a series of trades, based on the specified filters.
-func (c *WSClient) GetTrades(symbol string) (*WSGetTradesResponse, error) {
-	var request = WSGetTradesRequest{Symbol: symbol}
+func (c *WSClient) GetTrades(ctx context.Context, request WSGetTradesRequest) ([]PublicTrade, error) {
 	var response WSGetTradesResponse
 
-	err := c.conn.Call(context.Background(), "getSymbol", request, &response)
+	err := c.call(ctx, "getTrades", request, &response)
 	if err != nil {
-		return nil, errors.Annotate(err, "Hitbtc GetSymbol")
+		return nil, errors.Annotate(err, "Hitbtc GetTrades")
 	}
-	return &response, nil
+	return response.Data, nil
+}
+
+// GetCurrencies obtains the data of every currency, as GetCurrencies of the REST client.
+func (c *WSClient) GetCurrencies(ctx context.Context) ([]Currency, error) {
+	var response []Currency
+
+	err := c.call(ctx, "getCurrencies", struct{}{}, &response)
+	if err != nil {
+		return nil, errors.Annotate(err, "Hitbtc GetCurrencies")
+	}
+	return response, nil
+}
+
+// GetSymbols obtains the data of every market, as GetSymbols of the REST client.
+func (c *WSClient) GetSymbols(ctx context.Context) ([]Symbol, error) {
+	var response []Symbol
+
+	err := c.call(ctx, "getSymbols", struct{}{}, &response)
+	if err != nil {
+		return nil, errors.Annotate(err, "Hitbtc GetSymbols")
+	}
+	return response, nil
 }
 
 // wsSubscriptionResponse is the response for a subscribe/unsubscribe requests.
@@ -266,46 +667,62 @@ type WSSubscriptionRequest struct {
 	Symbol string `json:"symbol,required"`
 }
 
-// WSNotificationTickerResponse is notification response type on websocket
-type WSNotificationTickerResponse struct {
-	Ask         string `json:"ask,required"`         // Best ask price
-	Bid         string `json:"bid,required"`         // Best bid price
-	Last        string `json:"last,required"`        // Last trade price
-	Open        string `json:"open,required"`        // Last trade price 24 hours ago
-	Low         string `json:"low,required"`         // Lowest trade price within 24 hours
-	High        string `json:"high,required"`        // Highest trade price within 24 hours
-	Volume      string `json:"volume,required"`      // Total trading amount within 24 hours in base currency
-	VolumeQuote string `json:"volumeQuote,required"` // Total trading amount within 24 hours in quote currency
-	Timestamp   string `json:"timestamp,required"`   // Last update or refresh ticker timestamp
-	Symbol      string `json:"symbol,required"`
-}
+// WSNotificationTickerResponse is notification response type on websocket.
+// It is the Ticker returned by GetTicker.
+type WSNotificationTickerResponse = Ticker
 
 // SubscribeTicker subscribes to the specified market ticker notifications.
 func (c *WSClient) SubscribeTicker(symbol string) (<-chan WSNotificationTickerResponse, error) {
-	err := c.subscriptionOp("subscribeTicker", symbol)
+	return c.SubscribeTickerCtx(context.Background(), symbol)
+}
+
+// SubscribeTickerCtx is like SubscribeTicker but honors the cancellation and deadline of ctx.
+func (c *WSClient) SubscribeTickerCtx(ctx context.Context, symbol string) (<-chan WSNotificationTickerResponse, error) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	c.updates.mu.Lock()
+	opts := c.newFeedOptions(FeedTicker, "ticker:"+symbol, func() error { return c.UnsubscribeTicker(symbol) })
+	updates, created := openFeed(c.updates.notifications.TickerFeed, symbol, opts)
+	c.updates.mu.Unlock()
+
+	err := c.subscriptionOp(ctx, "subscribeTicker", symbol, created)
 	if err != nil {
+		if created {
+			c.updates.mu.Lock()
+			closeFeed(c.updates.notifications.TickerFeed, symbol, updates)
+			c.updates.mu.Unlock()
+		}
 		return nil, errors.Annotate(err, "Hitbtc SubscribeTicker")
 	}
 
-	if c.updates.notifications.TickerFeed[symbol] == nil {
-		c.updates.notifications.TickerFeed[symbol] = make(chan WSNotificationTickerResponse)
-	}
-
-	return c.updates.notifications.TickerFeed[symbol], nil
+	return updates.ch, nil
 }
 
 // UnsubscribeTicker subscribes to the specified market ticker notifications.
 //
-// This closes also the connected channel of updates.
+// This closes also the connected channel of updates. The handlers registered
+// with OnTicker are kept.
 func (c *WSClient) UnsubscribeTicker(symbol string) error {
-	err := c.subscriptionOp("unsubscribeTicker", symbol)
-	if err != nil {
-		return errors.Annotate(err, "Hitbtc UnsubscribeTicker")
-	}
+	return c.UnsubscribeTickerCtx(context.Background(), symbol)
+}
 
-	close(c.updates.notifications.TickerFeed[symbol])
-	delete(c.updates.notifications.TickerFeed, symbol)
+// UnsubscribeTickerCtx is like UnsubscribeTicker but honors the cancellation and deadline of ctx.
+func (c *WSClient) UnsubscribeTickerCtx(ctx context.Context, symbol string) error {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
 
+	c.updates.mu.Lock()
+	if c.updates.notifications.TickerFeed[symbol] == nil {
+		c.updates.mu.Unlock()
+		return nil
+	}
+	closeFeed(c.updates.notifications.TickerFeed, symbol, nil)
+	c.updates.mu.Unlock()
+
+	if err := c.subscriptionOp(ctx, "unsubscribeTicker", symbol, false); err != nil {
+		return errors.Annotate(err, "Hitbtc UnsubscribeTicker")
+	}
 	return nil
 }
 
@@ -321,54 +738,70 @@ type WSNotificationTradesUpdate struct {
 	Symbol string   `json:"symbol,required"`
 }
 
-// WSTrades is item for Trades
-type WSTrades struct {
-	ID        int    `json:"id,required"`
-	Price     string `json:"price,required"`
-	Quantity  string `json:"quantity"`
-	Side      string `json:"side,required"`
-	Timestamp string `json:"timestamp,required"`
-}
+// WSTrades is item for Trades: the PublicTrade returned by GetPublicTrades.
+type WSTrades = PublicTrade
 
 // SubscribeTrades subscribes to the specified market trades notifications.
 func (c *WSClient) SubscribeTrades(symbol string) (<-chan WSNotificationTradesUpdate, <-chan WSNotificationTradesSnapshot, error) {
-	err := c.subscriptionOp("subscribeTrades", symbol)
+	return c.SubscribeTradesCtx(context.Background(), symbol)
+}
+
+// SubscribeTradesCtx is like SubscribeTrades but honors the cancellation and deadline of ctx.
+func (c *WSClient) SubscribeTradesCtx(ctx context.Context, symbol string) (<-chan WSNotificationTradesUpdate, <-chan WSNotificationTradesSnapshot, error) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	c.updates.mu.Lock()
+	opts := c.newFeedOptions(FeedTrades, "trades:"+symbol, func() error { return c.UnsubscribeTrades(symbol) })
+	updates, created := openFeed(c.updates.notifications.TradesFeed, symbol, opts)
+	snapshots, _ := openFeed(c.updates.TradesFeed, symbol, opts)
+	c.updates.mu.Unlock()
+
+	err := c.subscriptionOp(ctx, "subscribeTrades", symbol, created)
 	if err != nil {
+		if created {
+			c.updates.mu.Lock()
+			closeFeed(c.updates.notifications.TradesFeed, symbol, updates)
+			closeFeed(c.updates.TradesFeed, symbol, snapshots)
+			c.updates.mu.Unlock()
+		}
 		return nil, nil, errors.Annotate(err, "Hitbtc SubscribeTrades")
 	}
 
-	if c.updates.notifications.TradesFeed[symbol] == nil {
-		c.updates.notifications.TradesFeed[symbol] = make(chan WSNotificationTradesUpdate)
-	}
-	if c.updates.TradesFeed[symbol] == nil {
-		c.updates.TradesFeed[symbol] = make(chan WSNotificationTradesSnapshot)
-	}
-
-	return c.updates.notifications.TradesFeed[symbol], c.updates.TradesFeed[symbol], nil
+	return updates.ch, snapshots.ch, nil
 }
 
 // UnsubscribeTrades unsubscribes from the specified market trades notifications and snapshot.
 //
-// This closes also the connected channel of updates.
+// This closes also the connected channel of updates. The handlers registered
+// with OnTrades are kept.
 func (c *WSClient) UnsubscribeTrades(symbol string) error {
-	err := c.subscriptionOp("unsubscribeTrades", symbol)
-	if err != nil {
-		return errors.Annotate(err, "Hitbtc UnsubscribeTrades")
-	}
+	return c.UnsubscribeTradesCtx(context.Background(), symbol)
+}
 
-	close(c.updates.notifications.TradesFeed[symbol])
-	delete(c.updates.notifications.TradesFeed, symbol)
-	close(c.updates.TradesFeed[symbol])
-	delete(c.updates.TradesFeed, symbol)
+// UnsubscribeTradesCtx is like UnsubscribeTrades but honors the cancellation and deadline of ctx.
+func (c *WSClient) UnsubscribeTradesCtx(ctx context.Context, symbol string) error {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
 
+	c.updates.mu.Lock()
+	if c.updates.notifications.TradesFeed[symbol] == nil {
+		c.updates.mu.Unlock()
+		return nil
+	}
+	closeFeed(c.updates.notifications.TradesFeed, symbol, nil)
+	closeFeed(c.updates.TradesFeed, symbol, nil)
+	c.updates.mu.Unlock()
+
+	if err := c.subscriptionOp(ctx, "unsubscribeTrades", symbol, false); err != nil {
+		return errors.Annotate(err, "Hitbtc UnsubscribeTrades")
+	}
 	return nil
 }
 
-// WSSubtypeTrade is element of market trade type
-type WSSubtypeTrade struct {
-	Price string `json:"price,required"`
-	Size  string `json:"size,required"`
-}
+// WSSubtypeTrade is element of market trade type: a level of an order book,
+// as returned by GetOrderbook.
+type WSSubtypeTrade = OrderBookItem
 
 // WSNotificationOrderbookSnapshot is notification response type to orderbook snapshot on websocket
 type WSNotificationOrderbookSnapshot struct {
@@ -388,120 +821,192 @@ type WSNotificationOrderbookUpdate struct {
 
 // SubscribeOrderbook subscribes to the specified market order book notifications.
 func (c *WSClient) SubscribeOrderbook(symbol string) (<-chan WSNotificationOrderbookUpdate, <-chan WSNotificationOrderbookSnapshot, error) {
-	err := c.subscriptionOp("subscribeOrderbook", symbol)
+	return c.SubscribeOrderbookCtx(context.Background(), symbol)
+}
+
+// SubscribeOrderbookCtx is like SubscribeOrderbook but honors the cancellation and deadline of ctx.
+func (c *WSClient) SubscribeOrderbookCtx(ctx context.Context, symbol string) (<-chan WSNotificationOrderbookUpdate, <-chan WSNotificationOrderbookSnapshot, error) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	c.updates.mu.Lock()
+	opts := c.newFeedOptions(FeedOrderbook, "orderbook:"+symbol, func() error { return c.UnsubscribeOrderbook(symbol) })
+	updates, created := openFeed(c.updates.notifications.OrderbookFeed, symbol, opts)
+	snapshots, _ := openFeed(c.updates.OrderbookFeed, symbol, opts)
+	c.updates.mu.Unlock()
+
+	err := c.subscriptionOp(ctx, "subscribeOrderbook", symbol, created)
 	if err != nil {
+		if created {
+			c.updates.mu.Lock()
+			closeFeed(c.updates.notifications.OrderbookFeed, symbol, updates)
+			closeFeed(c.updates.OrderbookFeed, symbol, snapshots)
+			c.updates.mu.Unlock()
+		}
 		return nil, nil, errors.Annotate(err, "Hitbtc SubscribeOrderbook")
 	}
 
-	if c.updates.notifications.OrderbookFeed[symbol] == nil {
-		c.updates.notifications.OrderbookFeed[symbol] = make(chan WSNotificationOrderbookUpdate)
-	}
-	if c.updates.OrderbookFeed[symbol] == nil {
-		c.updates.OrderbookFeed[symbol] = make(chan WSNotificationOrderbookSnapshot)
-	}
-
-	return c.updates.notifications.OrderbookFeed[symbol], c.updates.OrderbookFeed[symbol], nil
+	return updates.ch, snapshots.ch, nil
 }
 
 // UnsubscribeOrderbook unsubscribes from the specified market order book notifications and snapshot.
 //
-// This closes also the connected channel of updates.
+// This closes also the connected channel of updates. The handlers registered
+// with OnOrderbook are kept, and the notifications keep coming as long as an
+// OrderBook of the market is open.
 func (c *WSClient) UnsubscribeOrderbook(symbol string) error {
-	err := c.subscriptionOp("unsubscribeOrderbook", symbol)
-	if err != nil {
-		return errors.Annotate(err, "Hitbtc UnsubscribeOrderbook")
-	}
+	return c.UnsubscribeOrderbookCtx(context.Background(), symbol)
+}
+
+// UnsubscribeOrderbookCtx is like UnsubscribeOrderbook but honors the cancellation and deadline of ctx.
+func (c *WSClient) UnsubscribeOrderbookCtx(ctx context.Context, symbol string) error {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
 
-	close(c.updates.notifications.OrderbookFeed[symbol])
-	delete(c.updates.notifications.OrderbookFeed, symbol)
-	close(c.updates.OrderbookFeed[symbol])
-	delete(c.updates.OrderbookFeed, symbol)
+	c.updates.mu.Lock()
+	if c.updates.notifications.OrderbookFeed[symbol] == nil {
+		c.updates.mu.Unlock()
+		return nil
+	}
+	closeFeed(c.updates.notifications.OrderbookFeed, symbol, nil)
+	closeFeed(c.updates.OrderbookFeed, symbol, nil)
+	c.updates.mu.Unlock()
 
+	if err := c.subscriptionOp(ctx, "unsubscribeOrderbook", symbol, false); err != nil {
+		return errors.Annotate(err, "Hitbtc UnsubscribeOrderbook")
+	}
 	return nil
 }
 
 const (
 	// Interval30Minutes is 30 minutes interval for candle data.
-	Interval30Minutes string = "M30"
+	Interval30Minutes = PeriodM30
 	// Interval1Hour is 1 hour interval for candle data.
-	Interval1Hour string = "H1"
+	Interval1Hour = PeriodH1
 )
 
 // WSCandlesSubscriptionRequest is a request to subscribe for candle data.
 type WSCandlesSubscriptionRequest struct {
 	Symbol string `json:"symbol,required"`
-	Period string `json:"period,required"`
+	Period Period `json:"period,required"`
 }
 
 // WSNotificationCandlesSnapshot is subscribe response type to candles on websocket
 type WSNotificationCandlesSnapshot struct {
 	Data   []WSCandles `json:"data,required"`
 	Symbol string      `json:"symbol,required"`
-	Period string      `json:"period,required"`
+	Period Period      `json:"period,required"`
 }
 
 // WSNotificationCandlesUpdate is subscribe response type to candles on websocket
 type WSNotificationCandlesUpdate struct {
 	Data   WSCandles `json:"data,required"`
 	Symbol string    `json:"symbol,required"`
-	Period string    `json:"period,required"`
+	Period Period    `json:"period,required"`
 }
 
-// WSCandles is item for WSCandles
-type WSCandles struct {
-	Timestamp   time.Time `json:"timestamp,required"`
-	Open        string    `json:"open,required"`
-	Close       string    `json:"close,required"`
-	Min         string    `json:"min,required"`
-	Max         string    `json:"max,required"`
-	Volume      string    `json:"volume,required"`      // Total trading amount within 24 hours in base currency
-	VolumeQuote string    `json:"volumeQuote,required"` // Total trading amount within 24 hours in quote currency
-}
+// WSCandles is item for WSCandles: the Candle returned by GetCandles.
+type WSCandles = Candle
 
 // SubscribeCandles subscribes to the specified market candle notifications for the specified timeframe.
-func (c *WSClient) SubscribeCandles(symbol string, timeframe string) (<-chan WSNotificationCandlesUpdate, <-chan WSNotificationCandlesSnapshot, error) {
-	err := c.candlesSubscriptionOp("subscribeCandles", symbol, timeframe)
-	if err != nil {
-		return nil, nil, errors.Annotate(err, "Hitbtc SubscribeCandles")
-	}
+//
+// Each timeframe of a market has its own channels.
+func (c *WSClient) SubscribeCandles(symbol string, timeframe Period) (<-chan WSNotificationCandlesUpdate, <-chan WSNotificationCandlesSnapshot, error) {
+	return c.SubscribeCandlesCtx(context.Background(), symbol, timeframe)
+}
 
-	if c.updates.notifications.CandlesFeed[symbol] == nil {
-		c.updates.notifications.CandlesFeed[symbol] = make(chan WSNotificationCandlesUpdate)
-	}
+// SubscribeCandlesCtx is like SubscribeCandles but honors the cancellation and deadline of ctx.
+func (c *WSClient) SubscribeCandlesCtx(ctx context.Context, symbol string, timeframe Period) (<-chan WSNotificationCandlesUpdate, <-chan WSNotificationCandlesSnapshot, error) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	key := candlesKey{symbol, timeframe}
 
-	if c.updates.CandlesFeed[symbol] == nil {
-		c.updates.CandlesFeed[symbol] = make(chan WSNotificationCandlesSnapshot)
+	c.updates.mu.Lock()
+	opts := c.newFeedOptions(FeedCandles, "candles:"+symbol+":"+string(timeframe), func() error { return c.UnsubscribeCandles(symbol, timeframe) })
+	updates, created := openFeed(c.updates.notifications.CandlesFeed, key, opts)
+	snapshots, _ := openFeed(c.updates.CandlesFeed, key, opts)
+	c.updates.mu.Unlock()
+
+	err := c.candlesSubscriptionOp(ctx, "subscribeCandles", symbol, timeframe, created)
+	if err != nil {
+		if created {
+			c.updates.mu.Lock()
+			closeFeed(c.updates.notifications.CandlesFeed, key, updates)
+			closeFeed(c.updates.CandlesFeed, key, snapshots)
+			c.updates.mu.Unlock()
+		}
+		return nil, nil, errors.Annotate(err, "Hitbtc SubscribeCandles")
 	}
 
-	return c.updates.notifications.CandlesFeed[symbol], c.updates.CandlesFeed[symbol], nil
+	return updates.ch, snapshots.ch, nil
 }
 
 // UnsubscribeCandles unsubscribes from the specified market candle notifications for the specified timeframe.
 //
-// This closes also the connected channel of updates.
-func (c *WSClient) UnsubscribeCandles(symbol string, timeframe string) error {
-	err := c.candlesSubscriptionOp("unsubscribeCandles", symbol, timeframe)
-	if err != nil {
+// This closes also the connected channels of updates and snapshots of that
+// timeframe; its OnCandles handlers and the other timeframes of the market
+// are left subscribed.
+func (c *WSClient) UnsubscribeCandles(symbol string, timeframe Period) error {
+	return c.UnsubscribeCandlesCtx(context.Background(), symbol, timeframe)
+}
+
+// UnsubscribeCandlesCtx is like UnsubscribeCandles but honors the cancellation and deadline of ctx.
+func (c *WSClient) UnsubscribeCandlesCtx(ctx context.Context, symbol string, timeframe Period) error {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	key := candlesKey{symbol, timeframe}
+
+	c.updates.mu.Lock()
+	if c.updates.notifications.CandlesFeed[key] == nil {
+		c.updates.mu.Unlock()
+		return nil
+	}
+	closeFeed(c.updates.notifications.CandlesFeed, key, nil)
+	closeFeed(c.updates.CandlesFeed, key, nil)
+	c.updates.mu.Unlock()
+
+	if err := c.candlesSubscriptionOp(ctx, "unsubscribeCandles", symbol, timeframe, false); err != nil {
 		return errors.Annotate(err, "Hitbtc UnsubscribeCandles")
 	}
+	return nil
+}
 
-	close(c.updates.notifications.CandlesFeed[symbol])
-	delete(c.updates.notifications.CandlesFeed, symbol)
-	close(c.updates.CandlesFeed[symbol])
-	delete(c.updates.CandlesFeed, symbol)
+// subscriptionOp calls op, a subscribe or unsubscribe method, for symbol. See subscriptionCall.
+func (c *WSClient) subscriptionOp(ctx context.Context, op string, symbol string, ref bool) error {
+	feed := strings.TrimPrefix(strings.TrimPrefix(op, "un"), "subscribe")
+	return c.subscriptionCall(ctx, op, feed+":"+symbol, WSSubscriptionRequest{Symbol: symbol}, ref)
+}
 
-	return nil
+// candlesSubscriptionOp calls op, a subscribe or unsubscribe method, for the candles of symbol. See subscriptionCall.
+func (c *WSClient) candlesSubscriptionOp(ctx context.Context, op string, symbol string, period Period, ref bool) error {
+	return c.subscriptionCall(ctx, op, "Candles:"+symbol+":"+string(period), WSCandlesSubscriptionRequest{Symbol: symbol, Period: period}, ref)
 }
 
-func (c *WSClient) subscriptionOp(op string, symbol string) error {
-	if c.conn == nil {
-		return errors.New("Connection is unitialized")
+// subscriptionCall calls op for the subscription key. Feeds, handlers and
+// order books share the subscriptions of the client: each of them holds a
+// reference, taken by a subscribe with ref set, and released by an
+// unsubscribe, which is only sent to the server with the last reference.
+// A subscribe without ref asks for a new snapshot. c.subMu must be held.
+func (c *WSClient) subscriptionCall(ctx context.Context, op string, key string, request interface{}, ref bool) error {
+	subscribe := !strings.HasPrefix(op, "un")
+	if !subscribe {
+		c.mu.Lock()
+		c.refs[key]--
+		last := c.refs[key] <= 0
+		if last {
+			delete(c.refs, key)
+			delete(c.subscriptions, key)
+		}
+		c.mu.Unlock()
+		if !last {
+			return nil
+		}
 	}
 
-	var request = WSSubscriptionRequest{Symbol: symbol}
 	var success wsSubscriptionResponse
-
-	err := c.conn.Call(context.Background(), op, request, &success)
+	err := c.call(ctx, op, request, &success)
 	if err != nil {
 		return err
 	}
@@ -510,17 +1015,11 @@ func (c *WSClient) subscriptionOp(op string, symbol string) error {
 		return errors.New("Subscribe not successful")
 	}
 
-	return nil
-}
-
-func (c *WSClient) candlesSubscriptionOp(op string, symbol string, period string) error {
-	var request = WSCandlesSubscriptionRequest{Symbol: symbol, Period: period}
-	var response wsSubscriptionResponse
-
-	err := c.conn.Call(context.Background(), op, request, &response)
-	if err != nil {
-		return err
+	if subscribe && ref {
+		c.mu.Lock()
+		c.refs[key]++
+		c.subscriptions[key] = wsSubscription{op, request}
+		c.mu.Unlock()
 	}
-
 	return nil
 }
@@ -0,0 +1,372 @@
+package hitbtc_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func newWSClient(t *testing.T) *hitbtc.WSClient {
+	client, err := hitbtc.NewWSClient(hitbtc.WithWSURL(server.WSURL))
+	require.NoError(t, err, defaultErrorMessage)
+	return client
+}
+
+func TestWSGetSymbol(t *testing.T) {
+	client := newWSClient(t)
+	defer client.Close()
+
+	symbol, err := client.GetSymbol("ETHBTC")
+	t.Logf("GetSymbol : %#v\n", symbol)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, "ETH", symbol.BaseCurrency)
+
+	_, err = client.GetSymbol("NOPE")
+	require.Error(t, err)
+}
+
+func TestWSGetSymbolsAndCurrencies(t *testing.T) {
+	client := newWSClient(t)
+	defer client.Close()
+	ctx := context.Background()
+
+	symbols, err := client.GetSymbols(ctx)
+	require.NoError(t, err, defaultErrorMessage)
+	restSymbols, err := hitBtc.GetSymbols()
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, restSymbols, symbols)
+
+	currencies, err := client.GetCurrencies(ctx)
+	require.NoError(t, err, defaultErrorMessage)
+	restCurrencies, err := hitBtc.GetCurrencies()
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, restCurrencies, currencies)
+
+	symbol, err := client.GetSymbolCtx(ctx, restSymbols[0].Id)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, restSymbols[0], symbol)
+
+	currency, err := client.GetCurrencyCtx(ctx, restCurrencies[0].Id)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, restCurrencies[0], currency)
+
+	_, err = client.GetCurrencyCtx(ctx, "NOPE")
+	require.Error(t, err)
+}
+
+func TestWSGetTrades(t *testing.T) {
+	defer server.Reset()
+	client := newWSClient(t)
+	defer client.Close()
+	ctx := context.Background()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		server.AddPublicTrades("ETHBTC", hitbtc.PublicTrade{Id: uint64(i), Price: decimal.RequireFromString("0.07"), Quantity: decimal.NewFromInt(int64(i)), Side: "buy", Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	trades, err := client.GetTrades(ctx, hitbtc.WSGetTradesRequest{Symbol: "ETHBTC"})
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, trades, 5)
+	require.Equal(t, uint64(5), trades[0].Id, "newest first by default")
+	require.Equal(t, start.Add(5*time.Minute), trades[0].Timestamp)
+
+	from := start.Add(2 * time.Minute)
+	trades, err = client.GetTrades(ctx, hitbtc.WSGetTradesRequest{Symbol: "ETHBTC", TradesQuery: hitbtc.TradesQuery{Sort: hitbtc.SortAsc, From: from, Limit: 2, Offset: 1}})
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, trades, 2)
+	require.Equal(t, uint64(3), trades[0].Id)
+	require.Equal(t, uint64(4), trades[1].Id)
+
+	trades, err = client.GetTrades(ctx, hitbtc.WSGetTradesRequest{Symbol: "ETHBTC", TradesQuery: hitbtc.TradesQuery{Sort: hitbtc.SortAsc, By: hitbtc.TradesByID, FromID: 4}})
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, trades, 2)
+	require.Equal(t, uint64(4), trades[0].Id)
+
+	_, err = client.GetTrades(ctx, hitbtc.WSGetTradesRequest{Symbol: "NOPE"})
+	var apiErr *hitbtc.APIError
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	require.Equal(t, hitbtc.ErrCodeSymbolNotFound, apiErr.Code)
+}
+
+func TestWSSubscribeTicker(t *testing.T) {
+	client := newWSClient(t)
+	defer client.Close()
+
+	feed, err := client.SubscribeTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+
+	server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.RequireFromString("0.0712"), Bid: decimal.RequireFromString("0.071"), Timestamp: time.Now().UTC()})
+
+	select {
+	case ticker := <-feed:
+		t.Logf("Ticker : %#v\n", ticker)
+		require.Equal(t, "ETHBTC", ticker.Symbol)
+		require.Equal(t, "0.0712", ticker.Ask.String())
+
+		// the notification decodes like the REST ticker
+		rest, err := hitBtc.GetTicker("ETHBTC")
+		require.NoError(t, err, defaultErrorMessage)
+		require.Equal(t, rest, ticker)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ticker notification received")
+	}
+
+	require.NoError(t, client.UnsubscribeTicker("ETHBTC"))
+}
+
+func waitState(t *testing.T, events <-chan hitbtc.ConnectionEvent, state hitbtc.ConnectionState) hitbtc.ConnectionEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			require.True(t, ok, "events closed while waiting for %s", state)
+			if event.State == state {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("no %s event received", state)
+		}
+	}
+}
+
+func TestWSReconnect(t *testing.T) {
+	client, err := hitbtc.NewWSClient(hitbtc.WithWSURL(server.WSURL), hitbtc.WithWSReconnectPolicy(hitbtc.ReconnectPolicy{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}))
+	require.NoError(t, err, defaultErrorMessage)
+	defer client.Close()
+	events := client.ConnectionEvents()
+	waitState(t, events, hitbtc.Connected)
+
+	feed, err := client.SubscribeTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+
+	server.DropWSConnections()
+	event := waitState(t, events, hitbtc.Reconnecting)
+	require.Equal(t, hitbtc.ErrConnectionLost, event.Err)
+	waitState(t, events, hitbtc.Connected)
+
+	server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.RequireFromString("0.0713"), Timestamp: time.Now().UTC()})
+	select {
+	case ticker := <-feed:
+		require.Equal(t, "0.0713", ticker.Ask.String(), "the subscription is replayed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ticker notification received after reconnection")
+	}
+
+	client.Close()
+	waitState(t, events, hitbtc.Closed)
+	_, ok := <-events
+	require.False(t, ok, "events are closed after Closed")
+}
+
+func TestWSWithoutReconnect(t *testing.T) {
+	client, err := hitbtc.NewWSClient(hitbtc.WithWSURL(server.WSURL), hitbtc.WithoutWSReconnect())
+	require.NoError(t, err, defaultErrorMessage)
+	defer client.Close()
+
+	server.DropWSConnections()
+	event := waitState(t, client.ConnectionEvents(), hitbtc.Closed)
+	require.Equal(t, hitbtc.ErrConnectionLost, event.Err)
+}
+
+func TestWSHeartbeatWithUnreadFeed(t *testing.T) {
+	defer server.Reset()
+	heartbeat := 50 * time.Millisecond
+	client, err := hitbtc.NewWSClient(hitbtc.WithWSURL(server.WSURL), hitbtc.WithWSHeartbeat(heartbeat))
+	require.NoError(t, err, defaultErrorMessage)
+	defer client.Close()
+	events := client.ConnectionEvents()
+	waitState(t, events, hitbtc.Connected)
+
+	feed, err := client.SubscribeTickerCtx(context.Background(), "ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+
+	// more tickers than the feed buffer and the dispatch queue hold: the
+	// connection is not read until the feed is
+	const n = 2000
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 1; i <= n; i++ {
+			server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.NewFromInt(int64(i)), Timestamp: time.Now().UTC()})
+		}
+	}()
+	time.Sleep(10 * heartbeat)
+
+	for i := 1; i <= n; i++ {
+		select {
+		case ticker := <-feed:
+			require.Equal(t, decimal.NewFromInt(int64(i)).String(), ticker.Ask.String())
+		case event := <-events:
+			t.Fatalf("unexpected %s event (%v) after %d tickers", event.State, event.Err, i-1)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("ticker %d not received", i)
+		}
+	}
+	<-published
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected %s event (%v)", event.State, event.Err)
+	default:
+	}
+}
+
+func TestWSSubscribeCanceled(t *testing.T) {
+	client := newWSClient(t)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.SubscribeTickerCtx(ctx, "ETHBTC")
+	require.True(t, errors.Is(err, context.Canceled), "got %v", err)
+}
+
+func TestWSSubscribeCandlesPeriods(t *testing.T) {
+	defer server.Reset()
+	client := newWSClient(t)
+	defer client.Close()
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	server.SetCandles("ETHBTC", hitbtc.PeriodH1, []hitbtc.Candle{{Timestamp: start, Close: decimal.RequireFromString("0.07")}})
+
+	m30Updates, m30Snapshots, err := client.SubscribeCandles("ETHBTC", hitbtc.PeriodM30)
+	require.NoError(t, err, defaultErrorMessage)
+	h1Updates, h1Snapshots, err := client.SubscribeCandles("ETHBTC", hitbtc.PeriodH1)
+	require.NoError(t, err, defaultErrorMessage)
+
+	select {
+	case snapshot := <-m30Snapshots:
+		require.Equal(t, hitbtc.PeriodM30, snapshot.Period)
+		require.Empty(t, snapshot.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no M30 snapshot received")
+	}
+	select {
+	case snapshot := <-h1Snapshots:
+		require.Equal(t, hitbtc.PeriodH1, snapshot.Period)
+		require.Len(t, snapshot.Data, 1)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no H1 snapshot received")
+	}
+
+	require.NoError(t, client.UnsubscribeCandles("ETHBTC", hitbtc.PeriodM30))
+	_, ok := <-m30Updates
+	require.False(t, ok, "M30 updates should be closed")
+	_, ok = <-m30Snapshots
+	require.False(t, ok, "M30 snapshots should be closed")
+
+	server.PublishCandle("ETHBTC", hitbtc.PeriodH1, hitbtc.Candle{Timestamp: start.Add(time.Hour), Close: decimal.RequireFromString("0.071")})
+	select {
+	case update, ok := <-h1Updates:
+		require.True(t, ok, "H1 updates should stay open")
+		require.Equal(t, hitbtc.PeriodH1, update.Period)
+		require.Equal(t, "0.071", update.Data.Close.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no H1 update received")
+	}
+
+	require.NoError(t, client.UnsubscribeCandles("ETHBTC", hitbtc.PeriodH1))
+}
+
+// drain reads ch until it is closed, as a consumer of a feed would.
+func drain[T any](wg *sync.WaitGroup, ch <-chan T) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range ch {
+		}
+	}()
+}
+
+// TestWSConcurrentSubscriptions subscribes and unsubscribes from many goroutines
+// while notifications flow and the client is closed; run it with -race.
+func TestWSConcurrentSubscriptions(t *testing.T) {
+	defer server.Reset()
+	client := newWSClient(t)
+
+	stop := make(chan struct{})
+	var publisher sync.WaitGroup
+	publisher.Add(1)
+	go func() {
+		defer publisher.Done()
+		for i := 1; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			price := decimal.NewFromInt(int64(i))
+			server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: price, Timestamp: time.Now().UTC()})
+			server.PublishTicker(hitbtc.Ticker{Symbol: "BTCUSD", Ask: price, Timestamp: time.Now().UTC()})
+			server.PublishOrderbookUpdate("ETHBTC", []hitbtc.OrderBookItem{{Price: price, Size: decimal.NewFromInt(1)}}, nil)
+			server.PublishCandle("BTCUSD", hitbtc.PeriodM30, hitbtc.Candle{Timestamp: time.Unix(int64(i%4)*1800, 0).UTC(), Close: price})
+		}
+	}()
+
+	var readers, workers sync.WaitGroup
+	symbols := []string{"ETHBTC", "BTCUSD"}
+	for w := 0; w < 8; w++ {
+		workers.Add(1)
+		go func(w int) {
+			defer workers.Done()
+			symbol := symbols[w%len(symbols)]
+			for i := 0; i < 20; i++ {
+				if ticker, err := client.SubscribeTicker(symbol); err == nil {
+					drain(&readers, ticker)
+				}
+				if updates, snapshots, err := client.SubscribeOrderbook(symbol); err == nil {
+					drain(&readers, updates)
+					drain(&readers, snapshots)
+				}
+				if updates, snapshots, err := client.SubscribeTrades(symbol); err == nil {
+					drain(&readers, updates)
+					drain(&readers, snapshots)
+				}
+				if updates, snapshots, err := client.SubscribeCandles(symbol, hitbtc.PeriodM30); err == nil {
+					drain(&readers, updates)
+					drain(&readers, snapshots)
+				}
+				client.UnsubscribeTicker(symbol)
+				client.UnsubscribeOrderbook(symbol)
+				client.UnsubscribeTrades(symbol)
+				client.UnsubscribeCandles(symbol, hitbtc.PeriodM30)
+			}
+			// leave subscriptions open for Close
+			if ticker, err := client.SubscribeTicker(symbol); err == nil {
+				drain(&readers, ticker)
+			}
+		}(w)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	var closers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		closers.Add(1)
+		go func() {
+			defer closers.Done()
+			client.Close()
+		}()
+	}
+	workers.Wait()
+	closers.Wait()
+	close(stop)
+	publisher.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		readers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("feeds left open after Close")
+	}
+}
@@ -0,0 +1,84 @@
+package hitbtc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/juju/errors"
+	jsonrpc2 "github.com/sourcegraph/jsonrpc2"
+)
+
+// LoginAlgo is the authentication method of a websocket session.
+type LoginAlgo string
+
+const (
+	// LoginBasic sends the API key and secret.
+	LoginBasic LoginAlgo = "BASIC"
+	// LoginHS256 sends the API key and a HMAC-SHA256 signature of a random nonce;
+	// the secret never leaves the client.
+	LoginHS256 LoginAlgo = "HS256"
+)
+
+// WSLoginRequest is the login request type on websocket
+type WSLoginRequest struct {
+	Algo      LoginAlgo `json:"algo,required"`
+	PKey      string    `json:"pKey,required"`
+	SKey      string    `json:"sKey,omitempty"`
+	Nonce     string    `json:"nonce,omitempty"`
+	Signature string    `json:"signature,omitempty"`
+}
+
+// newLoginRequest builds a login request, with a new nonce for HS256.
+func newLoginRequest(key, secret string, algo LoginAlgo) (WSLoginRequest, error) {
+	request := WSLoginRequest{Algo: algo, PKey: key}
+	switch algo {
+	case LoginBasic:
+		request.SKey = secret
+	case LoginHS256:
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return request, err
+		}
+		request.Nonce = hex.EncodeToString(nonce)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(request.Nonce))
+		request.Signature = hex.EncodeToString(mac.Sum(nil))
+	default:
+		return request, errors.NotSupportedf("login algo %q", algo)
+	}
+	return request, nil
+}
+
+// Login authenticates the session, giving access to the trading methods.
+// The credentials are kept to log in again after a reconnection.
+func (c *WSClient) Login(ctx context.Context, key, secret string, algo LoginAlgo) error {
+	login := func(ctx context.Context, conn *jsonrpc2.Conn) error {
+		request, err := newLoginRequest(key, secret, algo)
+		if err != nil {
+			return err
+		}
+		var success wsSubscriptionResponse
+		if err := wsError(conn.Call(ctx, "login", request, &success)); err != nil {
+			return err
+		}
+		if !success {
+			return errors.New("Login not successful")
+		}
+		return nil
+	}
+
+	conn, err := c.current()
+	if err != nil {
+		return errors.Annotate(err, "Hitbtc Login")
+	}
+	if err := login(ctx, conn); err != nil {
+		return errors.Annotate(err, "Hitbtc Login")
+	}
+	c.mu.Lock()
+	c.relogin = login
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,76 @@
+package hitbtc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSLogin(t *testing.T) {
+	for _, algo := range []hitbtc.LoginAlgo{hitbtc.LoginBasic, hitbtc.LoginHS256} {
+		client := newWSClient(t)
+		require.NoError(t, client.Login(context.Background(), apiKey, apiSecret, algo), "%s: %s", algo, defaultErrorMessage)
+		require.Error(t, client.Login(context.Background(), apiKey, "wrong", algo), algo)
+		client.Close()
+	}
+
+	client := newWSClient(t)
+	defer client.Close()
+	require.Error(t, client.Login(context.Background(), apiKey, apiSecret, "RSA"))
+}
+
+func TestWSReloginOnReconnect(t *testing.T) {
+	client, err := hitbtc.NewWSClient(hitbtc.WithWSURL(server.WSURL), hitbtc.WithWSReconnectPolicy(hitbtc.ReconnectPolicy{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}))
+	require.NoError(t, err, defaultErrorMessage)
+	defer client.Close()
+	require.NoError(t, client.Login(context.Background(), apiKey, apiSecret, hitbtc.LoginHS256), defaultErrorMessage)
+
+	events := client.ConnectionEvents()
+	waitState(t, events, hitbtc.Connected)
+	server.DropWSConnections()
+	waitState(t, events, hitbtc.Reconnecting)
+	waitState(t, events, hitbtc.Connected)
+	require.Eventually(t, func() bool { return server.WSAuthenticatedSessions() == 1 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWSReconnectHoldsCallsUntilRelogin(t *testing.T) {
+	client, err := hitbtc.NewWSClient(hitbtc.WithWSURL(server.WSURL), hitbtc.WithWSReconnectPolicy(hitbtc.ReconnectPolicy{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}))
+	require.NoError(t, err, defaultErrorMessage)
+	defer client.Close()
+	require.NoError(t, client.Login(context.Background(), apiKey, apiSecret, hitbtc.LoginHS256), defaultErrorMessage)
+
+	// calls racing the reconnection may fail on the dropped connection, but
+	// never with an error of the server about a missing login
+	stop := make(chan struct{})
+	rejected := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				close(rejected)
+				return
+			default:
+			}
+			var apiErr *hitbtc.APIError
+			if _, err := client.GetTradingBalance(context.Background()); errors.As(err, &apiErr) {
+				rejected <- err
+				close(rejected)
+				return
+			}
+		}
+	}()
+
+	events := client.ConnectionEvents()
+	waitState(t, events, hitbtc.Connected)
+	for i := 0; i < 3; i++ {
+		server.DropWSConnections()
+		waitState(t, events, hitbtc.Reconnecting)
+		waitState(t, events, hitbtc.Connected)
+	}
+	close(stop)
+	require.NoError(t, <-rejected)
+}
@@ -0,0 +1,330 @@
+package hitbtc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/juju/errors"
+	jsonrpc2 "github.com/sourcegraph/jsonrpc2"
+	jsonrpc2ws "github.com/sourcegraph/jsonrpc2/websocket"
+)
+
+// ConnectionState is the state of the connection of a WSClient.
+type ConnectionState int
+
+const (
+	// Connected is reported when the connection is established, and again after every reconnection.
+	Connected ConnectionState = iota
+	// Reconnecting is reported when the connection is lost and after each failed reconnection attempt.
+	Reconnecting
+	// Closed is reported once, when the client is closed or gives up reconnecting.
+	Closed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case Connected:
+		return "connected"
+	case Reconnecting:
+		return "reconnecting"
+	case Closed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// ConnectionEvent is a change of the connection state of a WSClient.
+type ConnectionEvent struct {
+	State ConnectionState
+	Err   error // cause of the change; nil when connected or closed by Close
+}
+
+// ErrConnectionLost is the cause reported when the websocket connection drops
+// or misses its heartbeat.
+var ErrConnectionLost = errors.New("Hitbtc websocket connection lost")
+
+// ReconnectPolicy configures how a websocket client reconnects after losing its
+// connection. Unlike RetryPolicy, a zero MaxAttempts does not disable the
+// reconnection, see WithoutWSReconnect for that.
+type ReconnectPolicy struct {
+	MaxAttempts int           // attempts before the client is closed; ReconnectForever, or any value lower than 1, retries forever
+	MinBackoff  time.Duration // delay before the first attempt
+	MaxBackoff  time.Duration // upper bound of the exponential delay
+	Jitter      float64       // random fraction, between 0 and 1, added to or removed from each delay
+}
+
+// ReconnectForever is the MaxAttempts of a ReconnectPolicy retrying until the client is closed.
+const ReconnectForever = 0
+
+// DefaultReconnectPolicy is the reconnection policy of new websocket clients:
+// retry forever, waiting from half a second up to 30 seconds between attempts.
+var DefaultReconnectPolicy = ReconnectPolicy{
+	MaxAttempts: ReconnectForever,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+	Jitter:      0.2,
+}
+
+// backoff returns the delay before the given attempt (starting at 1).
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	return RetryPolicy{MinBackoff: p.MinBackoff, MaxBackoff: p.MaxBackoff, Jitter: p.Jitter}.backoff(attempt)
+}
+
+// exhausted reports whether no attempt is left after the given one.
+func (p ReconnectPolicy) exhausted(attempt int) bool {
+	return p.MaxAttempts > 0 && attempt >= p.MaxAttempts
+}
+
+// defaultHeartbeat is the ping interval of new websocket clients.
+const defaultHeartbeat = 30 * time.Second
+
+// wsSubscription is a subscribe call replayed after a reconnection.
+type wsSubscription struct {
+	method string
+	params interface{}
+}
+
+// ConnectionEvents returns the channel reporting the changes of the connection
+// state. It is closed after the Closed event. Events are dropped if the channel
+// is not read and its buffer is full.
+func (c *WSClient) ConnectionEvents() <-chan ConnectionEvent {
+	return c.events
+}
+
+// emit reports a connection event without blocking.
+func (c *WSClient) emit(event ConnectionEvent) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+	if c.eventsClosed {
+		return
+	}
+	select {
+	case c.events <- event:
+	default:
+	}
+	if event.State == Closed {
+		close(c.events)
+		c.eventsClosed = true
+	}
+}
+
+// dial opens a new connection to the websocket API.
+func (c *WSClient) dial() (*jsonrpc2.Conn, error) {
+	raw, _, err := websocket.DefaultDialer.Dial(c.config.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := c.config.heartbeat
+	if interval > 0 {
+		raw.SetReadDeadline(time.Now().Add(2 * interval))
+		raw.SetPongHandler(func(string) error {
+			return raw.SetReadDeadline(time.Now().Add(2 * interval))
+		})
+	}
+	var handler jsonrpc2.Handler = c.dispatcher
+	if interval > 0 {
+		handler = heartbeatHandler{c.dispatcher, raw, interval}
+	}
+	conn := jsonrpc2.NewConn(context.Background(), wsObjectStream{jsonrpc2ws.NewObjectStream(raw)}, handler)
+	if interval > 0 {
+		go heartbeat(raw, interval, conn.DisconnectNotify())
+	}
+	return conn, nil
+}
+
+// heartbeatHandler passes the messages of a connection with a heartbeat to the
+// dispatcher. Like a pong, a message shows that the connection is alive, so it
+// pushes back the read deadline; pongs queued behind many notifications would
+// come too late otherwise. While the dispatcher queue is full, the connection
+// is not read at all: the deadline is pushed back meanwhile too, so that a
+// slow consumer is not taken for a lost connection.
+type heartbeatHandler struct {
+	dispatcher *orderedHandler
+	raw        *websocket.Conn
+	interval   time.Duration
+}
+
+func (h heartbeatHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	h.alive()
+	h.dispatcher.enqueue(orderedRequest{ctx, conn, req}, h.interval, h.alive)
+}
+
+// alive pushes back the read deadline.
+func (h heartbeatHandler) alive() {
+	h.raw.SetReadDeadline(time.Now().Add(2 * h.interval))
+}
+
+// wsObjectStream reads the messages of the server. The errors of the server
+// carry their details in a description field, which jsonrpc2.Error does not
+// decode: it is passed on as the error data, where wsError reads it.
+type wsObjectStream struct {
+	jsonrpc2.ObjectStream
+}
+
+func (s wsObjectStream) ReadObject(v interface{}) error {
+	var raw json.RawMessage
+	if err := s.ObjectStream.ReadObject(&raw); err != nil {
+		return err
+	}
+	return json.Unmarshal(withErrorData(raw), v)
+}
+
+// withErrorData returns msg with the description of its error, if any, as the error data.
+func withErrorData(msg json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(msg, &fields) != nil || fields["error"] == nil {
+		return msg
+	}
+	var errFields map[string]json.RawMessage
+	if json.Unmarshal(fields["error"], &errFields) != nil || errFields["description"] == nil || errFields["data"] != nil {
+		return msg
+	}
+	errFields["data"] = errFields["description"]
+	rewritten, err := json.Marshal(errFields)
+	if err != nil {
+		return msg
+	}
+	fields["error"] = rewritten
+	if rewritten, err = json.Marshal(fields); err != nil {
+		return msg
+	}
+	return rewritten
+}
+
+// heartbeat pings the server every interval until done is closed. A connection
+// not answering is closed by its read deadline.
+func heartbeat(raw *websocket.Conn, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := raw.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+				raw.Close()
+				return
+			}
+		}
+	}
+}
+
+// current returns the current connection.
+func (c *WSClient) current() (*jsonrpc2.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil, errors.New("Connection is unitialized")
+	}
+	return c.conn, nil
+}
+
+// call calls method on the current connection.
+func (c *WSClient) call(ctx context.Context, method string, params, result interface{}) error {
+	conn, err := c.current()
+	if err != nil {
+		return err
+	}
+	return wsError(conn.Call(ctx, method, params, result))
+}
+
+// track records a successful subscribe call to replay it after a reconnection,
+// or forgets it after an unsubscribe call.
+func (c *WSClient) track(key string, subscribe bool, method string, params interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if subscribe {
+		c.subscriptions[key] = wsSubscription{method, params}
+	} else {
+		delete(c.subscriptions, key)
+	}
+}
+
+// watch waits for conn to drop and reconnects.
+func (c *WSClient) watch(conn *jsonrpc2.Conn) {
+	<-conn.DisconnectNotify()
+
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return
+	}
+	if c.config.reconnect == nil {
+		c.shutdown(ErrConnectionLost)
+		return
+	}
+	c.emit(ConnectionEvent{State: Reconnecting, Err: ErrConnectionLost})
+	c.updates.desyncBooks()
+
+	policy := *c.config.reconnect
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(policy.backoff(attempt)):
+		}
+
+		conn, err := c.reconnect()
+		if err == nil {
+			go c.watch(conn)
+			c.emit(ConnectionEvent{State: Connected})
+			return
+		}
+		if policy.exhausted(attempt) {
+			c.shutdown(errors.Annotate(err, "Hitbtc reconnect"))
+			return
+		}
+		c.emit(ConnectionEvent{State: Reconnecting, Err: err})
+	}
+}
+
+// reconnect opens a new connection, logs in again if needed and replays the
+// subscriptions. The connection is used by the other calls only once it is
+// ready, so that they never reach an unauthenticated session.
+func (c *WSClient) reconnect() (*jsonrpc2.Conn, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		conn.Close()
+		return nil, errors.New("Hitbtc websocket client closed")
+	}
+	relogin := c.relogin
+	subscriptions := make([]wsSubscription, 0, len(c.subscriptions))
+	for _, subscription := range c.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	c.mu.Unlock()
+
+	ctx := context.Background()
+	if relogin != nil {
+		if err := relogin(ctx, conn); err != nil {
+			conn.Close()
+			return nil, errors.Annotate(err, "Hitbtc relogin")
+		}
+	}
+	for _, subscription := range subscriptions {
+		var response wsSubscriptionResponse
+		if err := conn.Call(ctx, subscription.method, subscription.params, &response); err != nil {
+			conn.Close()
+			return nil, errors.Annotatef(err, "Hitbtc %s", subscription.method)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		conn.Close()
+		return nil, errors.New("Hitbtc websocket client closed")
+	}
+	c.conn = conn
+	return conn, nil
+}
@@ -0,0 +1,242 @@
+package hitbtc
+
+import (
+	"context"
+
+	"github.com/juju/errors"
+)
+
+// FeedEvents is the kind of the stream returned by WSClient.Events.
+const FeedEvents FeedKind = "events"
+
+// Event is a notification of the stream returned by WSClient.Events: a
+// TickerEvent, TradesEvent, OrderbookEvent, CandlesEvent or ReportEvent.
+type Event interface {
+	isEvent()
+}
+
+// TickerEvent is a ticker notification.
+type TickerEvent struct {
+	Ticker WSNotificationTickerResponse
+}
+
+// TradesEvent is a snapshot or an update of the trades of a market.
+type TradesEvent struct {
+	Symbol   string
+	Snapshot bool
+	Trades   []WSTrades
+}
+
+// OrderbookEvent is a snapshot or an update of the order book of a market.
+type OrderbookEvent struct {
+	Symbol   string
+	Snapshot bool
+	Ask      []WSSubtypeTrade
+	Bid      []WSSubtypeTrade
+	Sequence int64
+}
+
+// CandlesEvent is a snapshot or an update of the candles of a market.
+type CandlesEvent struct {
+	Symbol   string
+	Period   Period
+	Snapshot bool
+	Candles  []WSCandles
+}
+
+// ReportEvent is an execution report, see SubscribeReports.
+type ReportEvent struct {
+	Report Report
+}
+
+func (TickerEvent) isEvent()    {}
+func (TradesEvent) isEvent()    {}
+func (OrderbookEvent) isEvent() {}
+func (CandlesEvent) isEvent()   {}
+func (ReportEvent) isEvent()    {}
+
+// wsHandlers are the callbacks registered with the On methods, by subscription.
+// They are stored by pointer, so that a registration can be undone.
+type wsHandlers struct {
+	ticker    map[string][]*func(WSNotificationTickerResponse)
+	trades    map[string][]*func(bool, []WSTrades)
+	orderbook map[string][]*func(bool, []WSSubtypeTrade, []WSSubtypeTrade, int64)
+	candles   map[candlesKey][]*func(bool, []WSCandles)
+}
+
+func newWSHandlers() wsHandlers {
+	return wsHandlers{
+		ticker:    make(map[string][]*func(WSNotificationTickerResponse)),
+		trades:    make(map[string][]*func(bool, []WSTrades)),
+		orderbook: make(map[string][]*func(bool, []WSSubtypeTrade, []WSSubtypeTrade, int64)),
+		candles:   make(map[candlesKey][]*func(bool, []WSCandles)),
+	}
+}
+
+// addHandler registers fn for key and returns its registration. The lock guarding handlers must be held.
+func addHandler[K comparable, F any](handlers map[K][]*F, key K, fn F) *F {
+	h := &fn
+	handlers[key] = append(handlers[key], h)
+	return h
+}
+
+// removeHandler undoes the registration h and reports whether it was
+// registered. The lock guarding handlers must be held.
+func removeHandler[K comparable, F any](handlers map[K][]*F, key K, h *F) bool {
+	var kept []*F
+	for _, other := range handlers[key] {
+		if other != h {
+			kept = append(kept, other)
+		}
+	}
+	if len(kept) == len(handlers[key]) {
+		return false
+	}
+	if len(kept) == 0 {
+		delete(handlers, key)
+	} else {
+		handlers[key] = kept
+	}
+	return true
+}
+
+// onHandler registers a handler with add and takes a reference on its
+// subscription with subscribe. It returns the function undoing both: remove
+// takes the handler out, reporting whether it was still registered, and
+// unsubscribe releases the reference.
+func (c *WSClient) onHandler(add func(), remove func() bool, subscribe, unsubscribe func() error) (func() error, error) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	c.updates.mu.Lock()
+	add()
+	c.updates.mu.Unlock()
+
+	if err := subscribe(); err != nil {
+		c.updates.mu.Lock()
+		remove()
+		c.updates.mu.Unlock()
+		return nil, err
+	}
+
+	return func() error {
+		c.subMu.Lock()
+		defer c.subMu.Unlock()
+
+		c.updates.mu.Lock()
+		removed := remove()
+		c.updates.mu.Unlock()
+
+		if !removed {
+			return nil
+		}
+		return unsubscribe()
+	}, nil
+}
+
+// Events returns a stream merging the notifications of every subscription of
+// the client, whichever way it was made. Only the notifications received
+// after the first call are sent. The stream is buffered like the feeds of kind
+// FeedEvents, and closed when the client is closed.
+func (c *WSClient) Events() <-chan Event {
+	c.updates.mu.Lock()
+	defer c.updates.mu.Unlock()
+	if c.updates.events == nil {
+		select {
+		case <-c.done:
+			closed := make(chan Event)
+			close(closed)
+			return closed
+		default:
+		}
+		c.updates.events = newFeed[Event](c.newFeedOptions(FeedEvents, "events", c.closeEvents))
+	}
+	return c.updates.events.ch
+}
+
+// closeEvents closes the stream returned by Events.
+func (c *WSClient) closeEvents() error {
+	c.updates.mu.Lock()
+	defer c.updates.mu.Unlock()
+	if c.updates.events != nil {
+		c.updates.events.close()
+		c.updates.events = nil
+	}
+	return nil
+}
+
+// OnTicker calls fn with every ticker notification of symbol, subscribing to
+// them if needed. The returned function removes the handler, and unsubscribes
+// unless the notifications are still read otherwise.
+//
+// The On methods call their handlers from the goroutine reading the
+// notifications, in order of arrival, so handlers must not block. The handlers
+// are independent of the channels of the Subscribe methods: the Unsubscribe
+// methods leave them registered.
+func (c *WSClient) OnTicker(symbol string, fn func(WSNotificationTickerResponse)) (remove func() error, err error) {
+	var h *func(WSNotificationTickerResponse)
+	remove, err = c.onHandler(
+		func() { h = addHandler(c.updates.handlers.ticker, symbol, fn) },
+		func() bool { return removeHandler(c.updates.handlers.ticker, symbol, h) },
+		func() error { return c.subscriptionOp(context.Background(), "subscribeTicker", symbol, true) },
+		func() error { return c.subscriptionOp(context.Background(), "unsubscribeTicker", symbol, false) },
+	)
+	if err != nil {
+		return nil, errors.Annotate(err, "Hitbtc OnTicker")
+	}
+	return
+}
+
+// OnTrades calls fn with the trades snapshot of symbol, then with every new
+// trade, subscribing to them if needed. See OnTicker.
+func (c *WSClient) OnTrades(symbol string, fn func(snapshot bool, trades []WSTrades)) (remove func() error, err error) {
+	var h *func(bool, []WSTrades)
+	remove, err = c.onHandler(
+		func() { h = addHandler(c.updates.handlers.trades, symbol, fn) },
+		func() bool { return removeHandler(c.updates.handlers.trades, symbol, h) },
+		func() error { return c.subscriptionOp(context.Background(), "subscribeTrades", symbol, true) },
+		func() error { return c.subscriptionOp(context.Background(), "unsubscribeTrades", symbol, false) },
+	)
+	if err != nil {
+		return nil, errors.Annotate(err, "Hitbtc OnTrades")
+	}
+	return
+}
+
+// OnOrderbook calls fn with the order book snapshot of symbol, then with every
+// update, subscribing to them if needed. See OnTicker, and NewOrderBook to
+// maintain a local copy of the book.
+func (c *WSClient) OnOrderbook(symbol string, fn func(snapshot bool, ask, bid []WSSubtypeTrade, sequence int64)) (remove func() error, err error) {
+	var h *func(bool, []WSSubtypeTrade, []WSSubtypeTrade, int64)
+	remove, err = c.onHandler(
+		func() { h = addHandler(c.updates.handlers.orderbook, symbol, fn) },
+		func() bool { return removeHandler(c.updates.handlers.orderbook, symbol, h) },
+		func() error { return c.subscriptionOp(context.Background(), "subscribeOrderbook", symbol, true) },
+		func() error { return c.subscriptionOp(context.Background(), "unsubscribeOrderbook", symbol, false) },
+	)
+	if err != nil {
+		return nil, errors.Annotate(err, "Hitbtc OnOrderbook")
+	}
+	return
+}
+
+// OnCandles calls fn with the candles snapshot of symbol for timeframe, then
+// with every update, subscribing to them if needed. See OnTicker.
+func (c *WSClient) OnCandles(symbol string, timeframe Period, fn func(snapshot bool, candles []WSCandles)) (remove func() error, err error) {
+	key := candlesKey{symbol, timeframe}
+	var h *func(bool, []WSCandles)
+	remove, err = c.onHandler(
+		func() { h = addHandler(c.updates.handlers.candles, key, fn) },
+		func() bool { return removeHandler(c.updates.handlers.candles, key, h) },
+		func() error {
+			return c.candlesSubscriptionOp(context.Background(), "subscribeCandles", symbol, timeframe, true)
+		},
+		func() error {
+			return c.candlesSubscriptionOp(context.Background(), "unsubscribeCandles", symbol, timeframe, false)
+		},
+	)
+	if err != nil {
+		return nil, errors.Annotate(err, "Hitbtc OnCandles")
+	}
+	return
+}
@@ -0,0 +1,164 @@
+package hitbtc_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSOnHandlers(t *testing.T) {
+	defer server.Reset()
+	client := newWSClient(t)
+	defer client.Close()
+
+	server.AddPublicTrades("ETHBTC", hitbtc.PublicTrade{Id: 1, Price: decimal.RequireFromString("0.07"), Quantity: decimal.NewFromInt(1), Side: "buy", Timestamp: time.Now().UTC()})
+
+	tickers := make(chan hitbtc.WSNotificationTickerResponse, 10)
+	removeTicker, err := client.OnTicker("ETHBTC", func(ticker hitbtc.WSNotificationTickerResponse) {
+		tickers <- ticker
+	})
+	require.NoError(t, err, defaultErrorMessage)
+
+	type tradesCall struct {
+		snapshot bool
+		trades   []hitbtc.WSTrades
+	}
+	trades := make(chan tradesCall, 10)
+	_, err = client.OnTrades("ETHBTC", func(snapshot bool, t []hitbtc.WSTrades) {
+		trades <- tradesCall{snapshot, t}
+	})
+	require.NoError(t, err, defaultErrorMessage)
+
+	type orderbookCall struct {
+		snapshot bool
+		sequence int64
+	}
+	orderbooks := make(chan orderbookCall, 10)
+	_, err = client.OnOrderbook("ETHBTC", func(snapshot bool, ask, bid []hitbtc.WSSubtypeTrade, sequence int64) {
+		orderbooks <- orderbookCall{snapshot, sequence}
+	})
+	require.NoError(t, err, defaultErrorMessage)
+
+	server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.RequireFromString("0.0712"), Timestamp: time.Now().UTC()})
+	server.PublishTrade("ETHBTC", hitbtc.PublicTrade{Id: 2, Price: decimal.RequireFromString("0.071"), Quantity: decimal.NewFromInt(2), Side: "sell", Timestamp: time.Now().UTC()})
+	server.PublishOrderbookUpdate("ETHBTC", []hitbtc.OrderBookItem{{Price: decimal.RequireFromString("0.0705"), Size: decimal.NewFromInt(1)}}, nil)
+
+	select {
+	case ticker := <-tickers:
+		require.Equal(t, "0.0712", ticker.Ask.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ticker received")
+	}
+	for _, want := range []tradesCall{{true, []hitbtc.WSTrades{{Id: 1}}}, {false, []hitbtc.WSTrades{{Id: 2}}}} {
+		select {
+		case call := <-trades:
+			require.Equal(t, want.snapshot, call.snapshot)
+			require.Len(t, call.trades, 1)
+			require.Equal(t, want.trades[0].Id, call.trades[0].Id)
+			require.False(t, call.trades[0].Timestamp.IsZero())
+			require.False(t, call.trades[0].Price.IsZero())
+		case <-time.After(5 * time.Second):
+			t.Fatal("no trades received")
+		}
+	}
+	var calls []orderbookCall
+	for len(calls) < 2 {
+		select {
+		case call := <-orderbooks:
+			calls = append(calls, call)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no order book received")
+		}
+	}
+	require.True(t, calls[0].snapshot)
+	require.False(t, calls[1].snapshot)
+	require.Equal(t, calls[0].sequence+1, calls[1].sequence)
+
+	require.NoError(t, removeTicker())
+	require.NoError(t, removeTicker(), "removing twice is harmless")
+	server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.RequireFromString("0.0713"), Timestamp: time.Now().UTC()})
+	select {
+	case ticker := <-tickers:
+		t.Fatalf("ticker received after removing the handler: %v", ticker)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWSOnHandlersOutliveFeeds(t *testing.T) {
+	defer server.Reset()
+	client := newWSClient(t)
+	defer client.Close()
+
+	tickers := make(chan hitbtc.WSNotificationTickerResponse, 10)
+	remove, err := client.OnTicker("ETHBTC", func(ticker hitbtc.WSNotificationTickerResponse) {
+		tickers <- ticker
+	})
+	require.NoError(t, err, defaultErrorMessage)
+	feed, err := client.SubscribeTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	require.NoError(t, client.UnsubscribeTicker("ETHBTC"), defaultErrorMessage)
+	_, ok := <-feed
+	require.False(t, ok, "the feed should be closed")
+
+	server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.RequireFromString("0.0712"), Timestamp: time.Now().UTC()})
+	select {
+	case ticker := <-tickers:
+		require.Equal(t, "0.0712", ticker.Ask.String(), "the handler outlives the feed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ticker received after unsubscribing the feed")
+	}
+
+	feed, err = client.SubscribeTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	require.NoError(t, remove())
+	server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.RequireFromString("0.0713"), Timestamp: time.Now().UTC()})
+	select {
+	case ticker := <-feed:
+		require.Equal(t, "0.0713", ticker.Ask.String(), "the feed outlives the handler")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ticker received after removing the handler")
+	}
+}
+
+func TestWSEvents(t *testing.T) {
+	defer server.Reset()
+	client := newWSClient(t)
+	events := client.Events()
+
+	_, err := client.OnCandles("ETHBTC", hitbtc.PeriodM30, func(bool, []hitbtc.WSCandles) {})
+	require.NoError(t, err, defaultErrorMessage)
+	ticker, err := client.SubscribeTicker("ETHBTC")
+	require.NoError(t, err, defaultErrorMessage)
+	drain(new(sync.WaitGroup), ticker)
+
+	server.PublishTicker(hitbtc.Ticker{Symbol: "ETHBTC", Ask: decimal.RequireFromString("0.0712"), Timestamp: time.Now().UTC()})
+
+	var kinds []string
+	for len(kinds) < 2 {
+		select {
+		case event := <-events:
+			switch event := event.(type) {
+			case hitbtc.CandlesEvent:
+				require.True(t, event.Snapshot)
+				require.Equal(t, hitbtc.PeriodM30, event.Period)
+				kinds = append(kinds, "candles")
+			case hitbtc.TickerEvent:
+				require.Equal(t, "0.0712", event.Ticker.Ask.String())
+				kinds = append(kinds, "ticker")
+			default:
+				t.Fatalf("unexpected event %#v", event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+		}
+	}
+	require.Equal(t, []string{"candles", "ticker"}, kinds)
+
+	client.Close()
+	for range events {
+	}
+}
@@ -0,0 +1,201 @@
+package hitbtc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/juju/errors"
+	"github.com/shopspring/decimal"
+	jsonrpc2 "github.com/sourcegraph/jsonrpc2"
+)
+
+// WSNewOrderRequest is the new order request type on websocket
+type WSNewOrderRequest struct {
+	ClientOrderId string `json:"clientOrderId,required"`
+	Symbol        string `json:"symbol,required"`
+	Side          string `json:"side,required"`
+	Type          string `json:"type,omitempty"`
+	TimeInForce   string `json:"timeInForce,omitempty"`
+	Quantity      string `json:"quantity,required"`
+	Price         string `json:"price,omitempty"`
+	StopPrice     string `json:"stopPrice,omitempty"`
+}
+
+// WSCancelOrderRequest is the cancel order request type on websocket
+type WSCancelOrderRequest struct {
+	ClientOrderId string `json:"clientOrderId,required"`
+}
+
+// WSCancelReplaceOrderRequest is the cancel/replace order request type on websocket
+type WSCancelReplaceOrderRequest struct {
+	ClientOrderId   string `json:"clientOrderId,required"`
+	RequestClientId string `json:"requestClientId,required"`
+	Quantity        string `json:"quantity,required"`
+	Price           string `json:"price,required"`
+}
+
+// randomClientOrderId returns a random order id.
+func randomClientOrderId() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// wsError turns the JSON-RPC errors returned by the server into *APIError.
+// Their description is read from the data, where wsObjectStream puts it.
+func wsError(err error) error {
+	if rpcErr, ok := err.(*jsonrpc2.Error); ok {
+		apiErr := &APIError{Code: int(rpcErr.Code), Message: rpcErr.Message}
+		if rpcErr.Data != nil {
+			var description string
+			if json.Unmarshal(*rpcErr.Data, &description) == nil {
+				apiErr.Description = description
+			}
+		}
+		return apiErr
+	}
+	return err
+}
+
+// NewOrder places an order; the session must be logged in.
+// A random ClientOrderId is set when order has none.
+func (c *WSClient) NewOrder(ctx context.Context, order Order) (Order, error) {
+	request := WSNewOrderRequest{
+		ClientOrderId: order.ClientOrderId,
+		Symbol:        order.Symbol,
+		Side:          order.Side,
+		Type:          order.Type,
+		TimeInForce:   order.TimeInForce,
+		Quantity:      order.Quantity.String(),
+	}
+	if request.ClientOrderId == "" {
+		id, err := randomClientOrderId()
+		if err != nil {
+			return Order{}, errors.Annotate(err, "Hitbtc NewOrder")
+		}
+		request.ClientOrderId = id
+	}
+	if !order.Price.IsZero() {
+		request.Price = order.Price.String()
+	}
+	if !order.StopPrice.IsZero() {
+		request.StopPrice = order.StopPrice.String()
+	}
+
+	var response Order
+	if err := c.call(ctx, "newOrder", request, &response); err != nil {
+		return Order{}, errors.Annotate(err, "Hitbtc NewOrder")
+	}
+	return response, nil
+}
+
+// CancelOrder cancels an active order; the session must be logged in.
+func (c *WSClient) CancelOrder(ctx context.Context, clientOrderId string) (Order, error) {
+	var response Order
+	if err := c.call(ctx, "cancelOrder", WSCancelOrderRequest{ClientOrderId: clientOrderId}, &response); err != nil {
+		return Order{}, errors.Annotate(err, "Hitbtc CancelOrder")
+	}
+	return response, nil
+}
+
+// CancelReplaceOrder replaces an active order by a new one with another
+// quantity and price, named newClientOrderId (random when empty).
+// The session must be logged in.
+func (c *WSClient) CancelReplaceOrder(ctx context.Context, clientOrderId, newClientOrderId string, quantity, price decimal.Decimal) (Order, error) {
+	if newClientOrderId == "" {
+		id, err := randomClientOrderId()
+		if err != nil {
+			return Order{}, errors.Annotate(err, "Hitbtc CancelReplaceOrder")
+		}
+		newClientOrderId = id
+	}
+	request := WSCancelReplaceOrderRequest{
+		ClientOrderId:   clientOrderId,
+		RequestClientId: newClientOrderId,
+		Quantity:        quantity.String(),
+		Price:           price.String(),
+	}
+
+	var response Order
+	if err := c.call(ctx, "cancelReplaceOrder", request, &response); err != nil {
+		return Order{}, errors.Annotate(err, "Hitbtc CancelReplaceOrder")
+	}
+	return response, nil
+}
+
+// GetOrders returns the active orders; the session must be logged in.
+func (c *WSClient) GetOrders(ctx context.Context) ([]Order, error) {
+	var response []Order
+	if err := c.call(ctx, "getOrders", struct{}{}, &response); err != nil {
+		return nil, errors.Annotate(err, "Hitbtc GetOrders")
+	}
+	return response, nil
+}
+
+// GetTradingBalance returns the balances of the trading account; the session must be logged in.
+func (c *WSClient) GetTradingBalance(ctx context.Context) ([]Balance, error) {
+	var response []Balance
+	if err := c.call(ctx, "getTradingBalance", struct{}{}, &response); err != nil {
+		return nil, errors.Annotate(err, "Hitbtc GetTradingBalance")
+	}
+	return response, nil
+}
+
+// SubscribeReports subscribes to the execution reports of the orders of the
+// account; the session must be logged in. It returns the active orders,
+// followed by the reports of every later change.
+//
+// After a reconnection, the active orders are sent again on the channel as
+// ReportStatus reports.
+func (c *WSClient) SubscribeReports(ctx context.Context) ([]Report, <-chan Report, error) {
+	snapshot := make(chan []Report, 1)
+	c.updates.mu.Lock()
+	if c.updates.reports == nil {
+		c.updates.reports = newFeed[Report](c.newFeedOptions(FeedReports, "reports", c.closeReports))
+	}
+	reports := c.updates.reports
+	c.updates.reportsSnapshot = snapshot
+	c.updates.mu.Unlock()
+
+	forget := func() {
+		c.updates.mu.Lock()
+		if c.updates.reportsSnapshot == snapshot {
+			c.updates.reportsSnapshot = nil
+		}
+		c.updates.mu.Unlock()
+	}
+
+	var success wsSubscriptionResponse
+	if err := c.call(ctx, "subscribeReports", struct{}{}, &success); err != nil {
+		forget()
+		return nil, nil, errors.Annotate(err, "Hitbtc SubscribeReports")
+	}
+	c.track("Reports", true, "subscribeReports", struct{}{})
+
+	select {
+	case active := <-snapshot:
+		return active, reports.ch, nil
+	case <-ctx.Done():
+		forget()
+		return nil, nil, errors.Annotate(ctx.Err(), "Hitbtc SubscribeReports")
+	case <-c.done:
+		return nil, nil, errors.New("Hitbtc SubscribeReports: client closed")
+	}
+}
+
+// closeReports stops the delivery of the execution reports and closes their
+// channel. There is no unsubscribe method: the reports are still received, and discarded.
+func (c *WSClient) closeReports() error {
+	c.updates.mu.Lock()
+	if c.updates.reports != nil {
+		c.updates.reports.close()
+		c.updates.reports = nil
+	}
+	c.updates.mu.Unlock()
+	c.track("Reports", false, "", nil)
+	return nil
+}
@@ -0,0 +1,120 @@
+package hitbtc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	hitbtc "github.com/bitbandi/go-hitbtc"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSTrading(t *testing.T) {
+	defer server.Reset()
+	ctx := context.Background()
+	client := newWSClient(t)
+	defer client.Close()
+
+	_, err := client.GetOrders(ctx)
+	require.True(t, hitbtc.IsAuthError(err), "got %v", err)
+
+	require.NoError(t, client.Login(ctx, apiKey, apiSecret, hitbtc.LoginHS256), defaultErrorMessage)
+
+	order, err := client.NewOrder(ctx, hitbtc.Order{Symbol: "ETHBTC", Side: "buy", Type: "limit", Quantity: decimal.NewFromInt(1), Price: decimal.RequireFromString("0.07")})
+	require.NoError(t, err, defaultErrorMessage)
+	require.NotEmpty(t, order.ClientOrderId)
+	require.Equal(t, "new", order.Status)
+	require.Equal(t, "0.07", order.Price.String())
+
+	orders, err := client.GetOrders(ctx)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, orders, 1)
+
+	replaced, err := client.CancelReplaceOrder(ctx, order.ClientOrderId, "replacement", decimal.NewFromInt(2), decimal.RequireFromString("0.069"))
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, "replacement", replaced.ClientOrderId)
+	require.Equal(t, "2", replaced.Quantity.String())
+
+	balances, err := client.GetTradingBalance(ctx)
+	require.NoError(t, err, defaultErrorMessage)
+	for _, balance := range balances {
+		if balance.Currency == "BTC" {
+			require.Equal(t, "0.138", balance.Reserved.String())
+		}
+	}
+
+	canceled, err := client.CancelOrder(ctx, "replacement")
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, "canceled", canceled.Status)
+
+	_, err = client.CancelOrder(ctx, "replacement")
+	require.True(t, hitbtc.IsOrderNotFound(err), "got %v", err)
+
+	_, err = client.NewOrder(ctx, hitbtc.Order{Symbol: "ETHBTC", Side: "buy", Quantity: decimal.NewFromInt(1000), Price: decimal.NewFromInt(1)})
+	require.True(t, hitbtc.IsInsufficientFunds(err), "got %v", err)
+	var apiErr *hitbtc.APIError
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	require.Equal(t, "Check that the funds are sufficient, given commissions", apiErr.Description, "the description of the server is kept")
+}
+
+func TestWSReports(t *testing.T) {
+	defer server.Reset()
+	ctx := context.Background()
+	client := newWSClient(t)
+	defer client.Close()
+
+	_, _, err := client.SubscribeReports(ctx)
+	require.True(t, hitbtc.IsAuthError(err), "got %v", err)
+
+	require.NoError(t, client.Login(ctx, apiKey, apiSecret, hitbtc.LoginBasic), defaultErrorMessage)
+	active, err := client.NewOrder(ctx, hitbtc.Order{ClientOrderId: "active", Symbol: "ETHBTC", Side: "buy", Type: "limit", Quantity: decimal.NewFromInt(2), Price: decimal.RequireFromString("0.07")})
+	require.NoError(t, err, defaultErrorMessage)
+
+	snapshot, reports, err := client.SubscribeReports(ctx)
+	require.NoError(t, err, defaultErrorMessage)
+	require.Len(t, snapshot, 1)
+	require.Equal(t, hitbtc.ReportStatus, snapshot[0].ReportType)
+	require.Equal(t, active.ClientOrderId, snapshot[0].ClientOrderId)
+
+	next := func() hitbtc.Report {
+		select {
+		case report := <-reports:
+			return report
+		case <-time.After(time.Second):
+			require.FailNow(t, "no report received")
+		}
+		return hitbtc.Report{}
+	}
+
+	_, ok := server.FillOrder("active", decimal.NewFromInt(1))
+	require.True(t, ok)
+	report := next()
+	require.Equal(t, hitbtc.ReportTrade, report.ReportType)
+	require.True(t, report.IsPartialFill())
+	require.NotZero(t, report.TradeId)
+	require.Equal(t, "1", report.TradeQuantity.String())
+	require.Equal(t, "0.07", report.TradePrice.String())
+
+	_, err = client.CancelReplaceOrder(ctx, "active", "replacement", decimal.NewFromInt(1), decimal.RequireFromString("0.069"))
+	require.NoError(t, err, defaultErrorMessage)
+	report = next()
+	require.Equal(t, hitbtc.ReportReplaced, report.ReportType)
+	require.Equal(t, "replacement", report.ClientOrderId)
+	require.Equal(t, "active", report.OriginalRequestClientOrderId)
+
+	_, ok = server.FillOrder("replacement", decimal.NewFromInt(1))
+	require.True(t, ok)
+	report = next()
+	require.True(t, report.IsFilled())
+
+	_, err = client.NewOrder(ctx, hitbtc.Order{ClientOrderId: "canceled", Symbol: "ETHBTC", Side: "sell", Type: "limit", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(1)})
+	require.NoError(t, err, defaultErrorMessage)
+	require.Equal(t, hitbtc.ReportNew, next().ReportType)
+	_, err = client.CancelOrder(ctx, "canceled")
+	require.NoError(t, err, defaultErrorMessage)
+	report = next()
+	require.Equal(t, hitbtc.ReportCanceled, report.ReportType)
+	require.Equal(t, "canceled", report.Status)
+}